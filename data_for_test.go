@@ -10,6 +10,7 @@ package main_test
 import (
 	"os"
 	"regexp"
+	"runtime"
 	"strings"
 	"syscall"
 	"testing"
@@ -34,6 +35,8 @@ type CheckFunc func(t *testing.T, fixture Fixture, results Results)
 type FixtureConfig struct {
 	CliArgs []string
 	Env     map[string]string
+	// written to otel-cli's stdin when set
+	Stdin string
 	// timeout for how long to wait for the whole test in failure cases
 	TestTimeoutMs int
 	// when true this test will be excluded under go -test.short mode
@@ -41,6 +44,8 @@ type FixtureConfig struct {
 	IsLongTest bool
 	// either grpcProtocol or httpProtocol, defaults to grpc
 	ServerProtocol serverProtocol
+	// listen on a unix socket instead of TCP, {{endpoint}} becomes the socket path
+	ServerUnixSocket bool
 	// sets up the server with the test CA, requiring TLS
 	ServerTLSEnabled bool
 	// tells the server to require client certificate authentication
@@ -563,201 +568,1119 @@ var suites = []FixtureSuite{
 				SpanCount: 1,
 				CliOutput: "a z\n",
 				SpanData: map[string]string{
-					"attributes": "/^process.command=/bin/echo,process.command_args=/bin/echo,a,z,process.owner=\\w+,process.parent_pid=\\d+,process.pid=\\d+,zy=ab/",
+					// resource usage attributes vary by platform so are skipped over
+					"attributes": "/^process.command=/bin/echo,process.command_args=/bin/echo,a,z,(process\\.[a-z_.]+=\\w+,)*process.owner=\\w+,(process\\.[a-z_.]+=\\w+,)*process.parent_pid=\\d+,process.pid=\\d+,zy=ab/",
 				},
 			},
 		},
 	},
-	// #360: exec child exit code should propagate even when OTLP export fails
+	// exec records the child's resource usage, and samples it with --sample-interval
 	{
 		{
-			Name: "#360 exec child exit code preserved when export fails",
+			Name: "exec records resource usage",
 			Config: FixtureConfig{
 				CliArgs: []string{"exec",
 					"--endpoint", "{{endpoint}}",
-					"--timeout", "100ms",
-					"--", "/bin/sh", "-c", "exit 42",
+					"--verbose", "--fail",
+					"--sample-interval", "20ms",
+					"--", "/bin/sh", "-c", "sleep 0.2",
 				},
-				StopServerBeforeExec: true,
-				TestTimeoutMs:        2000,
-				IsLongTest:           true,
 			},
 			Expect: Results{
-				Config: otelcli.DefaultConfig(),
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
 			},
 			CheckFuncs: []CheckFunc{
 				func(t *testing.T, f Fixture, r Results) {
-					if r.ExitCode != 42 {
-						t.Errorf("expected exit code 42 from child process, got %d", r.ExitCode)
+					if runtime.GOOS != "linux" {
+						return // rusage details and /proc sampling are Linux-only
+					}
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					for _, key := range []string{"process.cpu.user_time_ms", "process.cpu.system_time_ms", "process.memory.max_rss_kb", "process.context_switches.voluntary"} {
+						if _, ok := attrs[key]; !ok {
+							t.Errorf("[%s] expected attribute %q on the exec span", f.Name, key)
+						}
+					}
+					if len(r.SpanEvents) == 0 || r.SpanEvents[0].Name != "process.sample" {
+						t.Errorf("[%s] expected process.sample events but got %v", f.Name, r.SpanEvents)
+					}
+				},
+			},
+		},
+	},
+	// exec records how the command exited and can map that to a span status
+	{
+		{
+			Name: "exec --status-map treats an exit code as ok",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--status-map", "1=ok",
+					"--", "/bin/sh", "-c", "exit 1",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				ExitCode:  1,
+				SpanData: map[string]string{
+					"status_code":        "1",
+					"status_description": "",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["process.exit.code"] != "1" || attrs["process.exit.reason"] != "exited" {
+						t.Errorf("[%s] expected process.exit.code=1 and process.exit.reason=exited but got %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --status-map sets an error description",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--status-map", "124=error:timed out",
+					"--", "/bin/sh", "-c", "exit 124",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				ExitCode:  124,
+				SpanData: map[string]string{
+					"status_code":        "2",
+					"status_description": "timed out",
+				},
+			},
+		},
+		{
+			Name: "exec records the signal that killed the command",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--", "/bin/sh", "-c", "kill -TERM $$",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"status_code":        "2",
+					"status_description": "exec command failed: signal: terminated",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["process.exit.signal"] != "SIGTERM" || attrs["process.exit.reason"] != "signaled" {
+						t.Errorf("[%s] expected process.exit.signal=SIGTERM and process.exit.reason=signaled but got %v", f.Name, attrs)
+					}
+					if _, ok := attrs["process.exit.code"]; ok {
+						t.Errorf("[%s] expected no process.exit.code for a signaled command", f.Name)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec records commands that fail to start",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--", "/nonexistent/otel-cli-test-command",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				ExitCode:  127,
+				SpanData: map[string]string{
+					"status_code":        "2",
+					"status_description": "/^exec command failed: .*no such file or directory/",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["process.exit.reason"] != "start_failed" {
+						t.Errorf("[%s] expected process.exit.reason=start_failed but got %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+	},
+	// exec --retries runs failed commands again, each attempt in a child span
+	{
+		{
+			Name: "exec --retries gives up after the last attempt",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--name", "flaky",
+					"--retries", "2",
+					"--retry-delay", "10ms",
+					"--", "/bin/sh", "-c", "exit 3 # {{span_id}}",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 4,
+				ExitCode:  3,
+				SpanData: map[string]string{
+					"name":               "flaky",
+					"status_code":        "2",
+					"status_description": "exec command failed: exit status 3",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["exec.retry.attempts"] != "3" || attrs["process.exit.code"] != "3" {
+						t.Errorf("[%s] expected exec.retry.attempts=3 and process.exit.code=3 but got %v", f.Name, attrs)
+					}
+					// the parent has the args as the last attempt recorded them, expanded
+					if args := attrs["process.command_args"]; strings.Contains(args, "{{span_id}}") || !strings.HasPrefix(args, "/bin/sh,-c,exit 3 # ") {
+						t.Errorf("[%s] expected the expanded args on the parent but got %q", f.Name, args)
+					}
+					if len(r.SpanEvents) != 2 || r.SpanEvents[1].Name != "exec.retry" {
+						t.Errorf("[%s] expected 2 exec.retry events but got %v", f.Name, r.SpanEvents)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --retry-on skips exit codes not listed",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--retries", "2",
+					"--retry-delay", "10ms",
+					"--retry-on", "75,timeout",
+					"--", "/bin/sh", "-c", "exit 3",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 2,
+				ExitCode:  3,
+				SpanData: map[string]string{
+					"status_code": "2",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if attempts := otlpclient.SpanAttributesToStringMap(r.Span)["exec.retry.attempts"]; attempts != "1" {
+						t.Errorf("[%s] expected exec.retry.attempts=1 but got %q", f.Name, attempts)
+					}
+				},
+			},
+		},
+	},
+	// otel-cli pipe turns lines of input into events and child spans
+	{
+		{
+			Name: "pipe copies stdin and records lines as events",
+			Config: FixtureConfig{
+				CliArgs: []string{"pipe",
+					"--endpoint", "{{endpoint}}",
+					"--name", "piped",
+					"--match", `^progress (?P<percent>\d+)%`,
+				},
+				Stdin: "starting\nprogress 50%\nprogress 100%\ndone\n",
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				CliOutput: "starting\nprogress 50%\nprogress 100%\ndone\n",
+				SpanData: map[string]string{
+					"name":       "piped",
+					"attributes": "pipe.bytes=41,pipe.lines=4",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if len(r.SpanEvents) != 2 {
+						t.Fatalf("[%s] expected 2 events but got %d", f.Name, len(r.SpanEvents))
+					}
+					attrs := otlpclient.SpanAttributesToStringMap(&tracepb.Span{Attributes: r.SpanEvents[1].Attributes})
+					if attrs["percent"] != "100" || attrs["pipe.line"] != "progress 100%" || attrs["pipe.line_number"] != "3" {
+						t.Errorf("[%s] unexpected event attributes %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+		{
+			Name: "pipe turns start and end lines into child spans",
+			Config: FixtureConfig{
+				CliArgs: []string{"pipe",
+					"--endpoint", "{{endpoint}}",
+					"--name", "build",
+					"--span-start", `^begin (?P<name>\w+)`,
+					"--span-end", `^end (?P<name>\w+) (?P<result>\w+)`,
+				},
+				Stdin: "begin compile\nbegin link\nend link ok\nend compile ok\n",
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 3,
+				CliOutput: "begin compile\nbegin link\nend link ok\nend compile ok\n",
+				SpanData: map[string]string{
+					"name": "build",
+				},
+			},
+		},
+	},
+	// otel-cli parallel runs jobs in child spans of one parent span
+	{
+		{
+			Name: "parallel runs each argument as a job",
+			Config: FixtureConfig{
+				CliArgs: []string{"parallel",
+					"--endpoint", "{{endpoint}}",
+					"--name", "fanout",
+					"--jobs", "2",
+					"--", "exit 0", "exit 3", "test -n \"$TRACEPARENT\"",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 4,
+				ExitCode:  1,
+				SpanData: map[string]string{
+					"name":               "fanout",
+					"status_code":        "2",
+					"status_description": "1 of 3 jobs failed",
+					"attributes":         "parallel.concurrency=2,parallel.jobs=3,parallel.jobs.failed=1",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if r.ExitCode != 1 {
+						t.Errorf("[%s] expected exit code 1 but got %d", f.Name, r.ExitCode)
+					}
+				},
+			},
+		},
+		{
+			Name: "parallel --command substitutes lines of stdin",
+			Config: FixtureConfig{
+				CliArgs: []string{"parallel",
+					"--endpoint", "{{endpoint}}",
+					"--jobs", "1",
+					"--command", "echo [{}]",
+				},
+				Stdin: "one\n\ntwo three\n",
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 3,
+				CliOutput: "[one]\n[two three]\n",
+				SpanData: map[string]string{
+					"attributes": "parallel.concurrency=1,parallel.jobs=2,parallel.jobs.failed=0",
+				},
+			},
+		},
+		{
+			Name: "parallel --fail-fast stops starting jobs",
+			Config: FixtureConfig{
+				CliArgs: []string{"parallel",
+					"--endpoint", "{{endpoint}}",
+					"--jobs", "1",
+					"--fail-fast",
+					"--", "exit 1", "echo never",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 2,
+				ExitCode:  1,
+				SpanData: map[string]string{
+					"status_code": "2",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if len(r.SpanEvents) != 1 || r.SpanEvents[0].Name != "parallel.fail_fast" {
+						t.Errorf("[%s] expected a parallel.fail_fast event but got %v", f.Name, r.SpanEvents)
+					}
+				},
+			},
+		},
+	},
+	// exec --side-channel lets the command add events, attributes, and spans
+	{
+		{
+			Name: "exec --side-channel fd applies records from the command",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--name", "migrate",
+					"--side-channel", "fd",
+					"--", "/bin/sh", "-c",
+					`echo type=event,name=hello >&$OTEL_CLI_FD; ` +
+						`echo type=start,name=inner >&$OTEL_CLI_FD; ` +
+						`echo type=end,rows=10 >&$OTEL_CLI_FD; ` +
+						`echo '{"type":"attrs","migrate.tables":3}' >&$OTEL_CLI_FD; ` +
+						`echo type=status,code=error,description=partial >&$OTEL_CLI_FD`,
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 2,
+				SpanData: map[string]string{
+					"name":               "migrate",
+					"status_code":        "2",
+					"status_description": "partial",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if attrs := otlpclient.SpanAttributesToStringMap(r.Span); attrs["migrate.tables"] != "3" {
+						t.Errorf("[%s] expected migrate.tables=3 but got %v", f.Name, attrs)
+					}
+					if len(r.SpanEvents) != 1 || r.SpanEvents[0].Name != "hello" {
+						t.Errorf("[%s] expected a hello event but got %v", f.Name, r.SpanEvents)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --side-channel fifo replaces an outer otel-cli's fifo",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--side-channel", "fifo",
+					"--", "/bin/sh", "-c", `echo type=event,name=hello > "$OTEL_CLI_FIFO"`,
+				},
+				Env: map[string]string{
+					"OTEL_CLI_FIFO": "/nonexistent/outer.fifo",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if len(r.SpanEvents) != 1 || r.SpanEvents[0].Name != "hello" {
+						t.Errorf("[%s] expected a hello event but got %v", f.Name, r.SpanEvents)
 					}
 				},
 			},
 		},
-	},
-	// otel-cli span with no OTLP config should do and print nothing
-	{
+	},
+	// exec --pty runs the command on a pseudo-terminal
+	{
+		{
+			Name: "exec --pty gives the command a terminal and captures its output",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--pty",
+					"--capture",
+					"--", "/bin/sh", "-c", "test -t 0 && test -t 1 && test -t 2 && echo tty >&2; exit 4",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				ExitCode:  4,
+				CliOutput: "tty\r\n",
+				SpanData: map[string]string{
+					"status_code": "2",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["process.stdout.bytes"] != "5" || attrs["process.exit.code"] != "4" {
+						t.Errorf("[%s] expected process.stdout.bytes=5 and process.exit.code=4 but got %v", f.Name, attrs)
+					}
+					if _, ok := attrs["process.stderr.bytes"]; ok {
+						t.Errorf("[%s] expected no process.stderr.bytes with --pty", f.Name)
+					}
+					if len(r.SpanEvents) != 1 || r.SpanEvents[0].Name != "process.stdout" {
+						t.Errorf("[%s] expected a process.stdout tail event but got %v", f.Name, r.SpanEvents)
+					}
+				},
+			},
+		},
+	},
+	// exec --relay gives the command a local OTLP endpoint and forwards upstream
+	{
+		{
+			Name: "exec --relay forwards spans from the command",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--name", "relayed",
+					"--relay", "grpc",
+					"--", "./otel-cli", "span", "--name", "inner",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 2,
+				SpanData: map[string]string{
+					"name": "relayed",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["exec.relay.spans"] != "1" || attrs["exec.relay.unrelated_spans"] != "0" || attrs["exec.relay.failed_spans"] != "0" {
+						t.Errorf("[%s] expected one related span to be relayed but got %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --relay forwards log records from the command",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--name", "relayed",
+					"--relay", "grpc",
+					"--", "./otel-cli", "exec", "--name", "inner", "--logs", "--", "echo", "hello",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 2,
+				CliOutput: "hello\n",
+				SpanData: map[string]string{
+					"name": "relayed",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["exec.relay.spans"] != "1" || attrs["exec.relay.log_records"] != "1" || attrs["exec.relay.failed_log_records"] != "0" {
+						t.Errorf("[%s] expected one span and one log record to be relayed but got %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --logs sends each line of output as a log record",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--name", "logged",
+					"--logs",
+					"--", "/bin/sh", "-c", "echo 'step failed' >&2; sleep 0.1; echo out; printf partial",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				CliOutput: "step failed\nout\npartial",
+				SpanData: map[string]string{
+					"name": "logged",
+				},
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					attrs := otlpclient.SpanAttributesToStringMap(r.Span)
+					if attrs["exec.logs.records"] != "3" || attrs["exec.logs.failed_records"] != "0" {
+						t.Errorf("[%s] expected 3 log records to be sent but got %v", f.Name, attrs)
+					}
+				},
+			},
+		},
+		{
+			Name: "exec --export-env passes the config to the command without secrets",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--service", "deployer",
+					"--otlp-headers", "x-team=obs,authorization=Bearer abc123",
+					"--export-env",
+					"--", "/bin/sh", "-c", "env | grep ^OTEL_ | grep -v ENDPOINT | sort",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				CliOutput: "OTEL_EXPORTER_OTLP_HEADERS=x-team=obs\n" +
+					"OTEL_EXPORTER_OTLP_PROTOCOL=grpc\n" +
+					"OTEL_EXPORTER_OTLP_TIMEOUT=1000\n" +
+					"OTEL_RESOURCE_ATTRIBUTES=service.name=deployer\n" +
+					"OTEL_SERVICE_NAME=deployer\n",
+			},
+		},
+	},
+	// #360: exec child exit code should propagate even when OTLP export fails
+	{
+		{
+			Name: "#360 exec child exit code preserved when export fails",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--timeout", "100ms",
+					"--", "/bin/sh", "-c", "exit 42",
+				},
+				StopServerBeforeExec: true,
+				TestTimeoutMs:        2000,
+				IsLongTest:           true,
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					if r.ExitCode != 42 {
+						t.Errorf("expected exit code 42 from child process, got %d", r.ExitCode)
+					}
+				},
+			},
+		},
+	},
+	// otel-cli span with no OTLP config should do and print nothing
+	{
+		{
+			Name: "otel-cli span (unconfigured, non-recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--service", "main_test.go", "--name", "test-span-123", "--kind", "server"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+	},
+	// config file
+	{
+		{
+			Name: "load a json config file",
+			Config: FixtureConfig{
+				CliArgs: []string{"status", "--config", "example-config.json"},
+				// this will take priority over the config
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+				},
+				TestTimeoutMs: 1000,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Diagnostics: otelcli.Diagnostics{
+					IsRecording:       true,
+					NumArgs:           3,
+					ParsedTimeoutMs:   1000,
+					Endpoint:          "*",
+					EndpointSource:    "*",
+					DetectedLocalhost: true,
+					Error:             "could not open file '/tmp/traceparent.txt' for read: open /tmp/traceparent.txt: no such file or directory",
+				},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+				},
+				Config: otelcli.DefaultConfig().
+					WithEndpoint("{{endpoint}}"). // tells the test framework to ignore/overwrite
+					WithTimeout("1s").
+					WithHeaders(map[string]string{"header1": "header1-value"}).
+					WithInsecure(true).
+					WithBlocking(false).
+					WithTlsNoVerify(true).
+					WithTlsCACert("/dev/null").
+					WithTlsClientKey("/dev/null").
+					WithTlsClientCert("/dev/null").
+					WithServiceName("configured_in_config_file").
+					WithSpanName("config_file_span").
+					WithKind("server").
+					WithAttributes(map[string]string{"attr1": "value1"}).
+					WithStatusCode("0").
+					WithStatusDescription("status description").
+					WithTraceparentCarrierFile("/tmp/traceparent.txt").
+					WithTraceparentIgnoreEnv(true).
+					WithTraceparentPrint(true).
+					WithTraceparentPrintExport(true).
+					WithTraceparentRequired(false).
+					WithBackgroundParentPollMs(100).
+					WithBackgroundSockdir("/tmp").
+					WithBackgroundWait(true).
+					WithSpanEndTime("now").
+					WithSpanEndTime("now").
+					WithEventName("config_file_event").
+					WithEventTime("now").
+					WithCfgFile("example-config.json").
+					WithVerbose(true).
+					WithFail(true),
+			},
+		},
+	},
+	// otel-cli with minimal config span sends a span that looks right
+	{
+		{
+			Name: "otel-cli span (recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--service", "main_test.go", "--name", "test-span-123", "--kind", "server"},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+				},
+				TestTimeoutMs: 1000,
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+			},
+		},
+		// OTEL_RESOURCE_ATTRIBUTES and OTEL_CLI_SERVICE_NAME should get merged into
+		// the span resource attributes
+		{
+			Name: "otel-cli span with envvar service name and attributes (recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--name", "test-span-service-name-and-attrs", "--kind", "server"},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+					"OTEL_CLI_SERVICE_NAME":       "test-service-abc123",
+					"OTEL_CLI_ATTRIBUTES":         "cafe=deadbeef,abc=123",
+					"OTEL_RESOURCE_ATTRIBUTES":    "foo.bar=baz",
+				},
+				TestTimeoutMs: 1000,
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				SpanData: map[string]string{
+					"span_id":            "*",
+					"trace_id":           "*",
+					"attributes":         "abc=123,cafe=deadbeef",
+					"service_attributes": "foo.bar=baz,service.name=test-service-abc123",
+				},
+				SpanCount: 1,
+			},
+		},
+		// OTEL_SERVICE_NAME
+		{
+			Name: "otel-cli span with envvar service name (recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span"},
+				Env: map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+					"OTEL_SERVICE_NAME":           "test-service-123abc",
+				},
+				TestTimeoutMs: 1000,
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				SpanData: map[string]string{
+					"service_attributes": "service.name=test-service-123abc",
+				},
+				SpanCount: 1,
+			},
+		},
+	},
+	// otel-cli span --print-tp actually prints
+	{
+		{
+			Name: "otel-cli span --print-tp (non-recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--tp-print"},
+				Env:     map[string]string{"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01"},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: "" + // empty so the text below can indent and line up
+					"# trace id: f6c109f48195b451c4def6ab32f47b61\n" +
+					"#  span id: a5d2a35f2483004e\n" +
+					"TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01\n",
+			},
+		},
+	},
+	// otel-cli span --print-tp propagates traceparent even when not recording
+	{
+		{
+			Name: "otel-cli span --tp-print --tp-export (non-recording)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "--tp-print", "--tp-export"},
+				Env: map[string]string{
+					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00",
+				},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: "" +
+					"# trace id: f6c109f48195b451c4def6ab32f47b61\n" +
+					"#  span id: a5d2a35f2483004e\n" +
+					"export TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00\n",
+			},
+		},
+	},
+	// --output json and shell print everything about the span for scripts
+	{
+		{
+			Name: "otel-cli span --output json",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "--endpoint", "{{endpoint}}",
+					"--force-span-id", "beefcafefacedead",
+					"--start", "2023-11-14T22:13:20Z", "--end", "2023-11-14T22:13:21.5Z",
+					"--output", "json",
+				},
+				Env: map[string]string{
+					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01",
+					"TRACESTATE":  "vendor=abc",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				SpanCount: 1,
+				CliOutput: `{"trace_id":"f6c109f48195b451c4def6ab32f47b61","span_id":"beefcafefacedead",` +
+					`"parent":"a5d2a35f2483004e","traceparent":"00-f6c109f48195b451c4def6ab32f47b61-beefcafefacedead-01",` +
+					`"tracestate":"vendor=abc","start":"2023-11-14T22:13:20Z","end":"2023-11-14T22:13:21.5Z",` +
+					`"duration_ms":1500,"exit_code":0,"errors":[]}` + "\n",
+			},
+		},
+		{
+			Name: "otel-cli exec --output shell",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"exec", "--endpoint", "{{endpoint}}",
+					"--force-trace-id", "f6c109f48195b451c4def6ab32f47b61", "--force-span-id", "beefcafefacedead",
+					"--output", "shell", "--tp-export",
+					"--", "sh", "-c", "exit 3",
+				},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				SpanCount: 1,
+				// the times and duration vary from run to run
+				CliOutputRe: regexp.MustCompile(`\d{4}-\d{2}-\d{2}T[\d:.]+Z|\d+\.\d+`),
+				CliOutput: "OTEL_CLI_TRACE_ID=f6c109f48195b451c4def6ab32f47b61\n" +
+					"OTEL_CLI_SPAN_ID=beefcafefacedead\n" +
+					"OTEL_CLI_PARENT_SPAN_ID=\n" +
+					"export TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-beefcafefacedead-01\n" +
+					"OTEL_CLI_SPAN_START=\n" +
+					"OTEL_CLI_SPAN_END=\n" +
+					"OTEL_CLI_DURATION_MS=\n" +
+					"OTEL_CLI_EXIT_CODE=3\n" +
+					"OTEL_CLI_ERRORS=''\n",
+			},
+		},
+	},
+	// otel-cli span background steps are sent along with the background span
+	{
+		{
+			Name: "otel-cli span background (recording) with steps",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "background", "--timeout", "1s", "--sockdir", ".", "--name", "script",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "aaaaaaaaaaaaaaaa",
+				},
+				Env:           map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
+				TestTimeoutMs: 2000,
+				Background:    true,
+				Foreground:    false,
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				// the background span is sent last, after its steps
+				SpanData: map[string]string{
+					"span_id": "aaaaaaaaaaaaaaaa",
+					"name":    "script",
+				},
+				SpanCount: 3,
+			},
+		},
+		{
+			Name: "otel-cli span background step start",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "background", "step", "start", "--sockdir", ".", "--name", "build", "--tp-print"},
+			},
+			Expect: Results{
+				Config:      otelcli.DefaultConfig(),
+				CliOutputRe: regexp.MustCompile(`\b[0-9a-f]{16}\b`), // the step's span id
+				CliOutput: "" +
+					"# trace id: e39280f2980af3a8600ae98c74f2dabf\n" +
+					"#  span id: \n" +
+					"TRACEPARENT=00-e39280f2980af3a8600ae98c74f2dabf--01\n",
+			},
+		},
+		{
+			Name: "otel-cli span background step start (nested)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "background", "step", "start", "--sockdir", ".", "--name", "compile"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span background step end (outer step ends the nested one too)",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "background", "step", "end", "--sockdir", ".", "--step", "build"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span end",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "end", "--sockdir", "."},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span background (recording) with steps",
+			Config: FixtureConfig{
+				Foreground: true,
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+	},
+	// otel-cli span background --bg-name runs more than one background span
+	// in a sockdir, span event and span end pick one by name
+	{
+		{
+			Name: "otel-cli span background --bg-name alpha",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "background", "--timeout", "1s", "--sockdir", ".", "--bg-name", "alpha", "--name", "script-alpha",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "aaaaaaaaaaaaaaaa",
+				},
+				Env:           map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
+				TestTimeoutMs: 2000,
+				Background:    true,
+			},
+			Expect: Results{
+				Config:     otelcli.DefaultConfig(),
+				SpanCount:  1,
+				EventCount: 1,
+				SpanData: map[string]string{
+					"span_id": "aaaaaaaaaaaaaaaa",
+					"name":    "script-alpha",
+				},
+			},
+		},
+		{
+			Name: "otel-cli span background --bg-name beta",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "background", "--timeout", "1s", "--sockdir", ".", "--bg-name", "beta", "--name", "script-beta",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "bbbbbbbbbbbbbbbb",
+				},
+				Env:           map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
+				TestTimeoutMs: 2000,
+				Background:    true,
+			},
+			Expect: Results{
+				Config:     otelcli.DefaultConfig(),
+				SpanCount:  1,
+				EventCount: 0,
+				SpanData: map[string]string{
+					"span_id": "bbbbbbbbbbbbbbbb",
+					"name":    "script-beta",
+				},
+			},
+		},
+		{
+			Name: "otel-cli span event --bg-name alpha",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "event", "--sockdir", ".", "--bg-name", "alpha", "--name", "only on alpha"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span background --wait --bg-name beta",
+			Config: FixtureConfig{
+				// span event waits for alpha's socket, list doesn't so make sure beta is up too
+				CliArgs: []string{"span", "background", "--wait", "--sockdir", ".", "--bg-name", "beta"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span background list",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "background", "list", "--sockdir", "."},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: "" +
+					"alpha\t00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01\tscript-alpha\n" +
+					"beta\t00-e39280f2980af3a8600ae98c74f2dabf-bbbbbbbbbbbbbbbb-01\tscript-beta\n",
+			},
+		},
+		{
+			Name: "otel-cli span end --bg-name beta",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "end", "--sockdir", ".", "--bg-name", "beta"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name: "otel-cli span end --bg-name alpha",
+			Config: FixtureConfig{
+				CliArgs: []string{"span", "end", "--sockdir", ".", "--bg-name", "alpha"},
+			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name:   "otel-cli span background --bg-name alpha",
+			Config: FixtureConfig{Foreground: true},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+		{
+			Name:   "otel-cli span background --bg-name beta",
+			Config: FixtureConfig{Foreground: true},
+			Expect: Results{Config: otelcli.DefaultConfig()},
+		},
+	},
+	// otel-cli span background set changes the running span before it ends
+	{
+		{
+			Name: "otel-cli span background (recording) changed with set",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "background", "--timeout", "1s", "--sockdir", ".", "--name", "first",
+					"--attrs", "deploy.pending=yes,keep=yes",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "aaaaaaaaaaaaaaaa",
+				},
+				Env:           map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
+				TestTimeoutMs: 2000,
+				Background:    true,
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"span_id":            "aaaaaaaaaaaaaaaa",
+					"name":               "deploy",
+					"attributes":         "deploy.version=v1,keep=yes",
+					"status_code":        "2",
+					"status_description": "rolled back",
+				},
+			},
+		},
+		{
+			Name: "otel-cli span background set",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"span", "background", "set", "--sockdir", ".", "--name", "deploy",
+					"--attrs", "deploy.version=v1", "--remove-attr", "deploy.pending",
+					"--status-code", "error", "--status-description", "rolled back",
+					"--start", "2026-10-18T00:00:00Z", "--output", "json",
+				},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: `{"trace_id":"e39280f2980af3a8600ae98c74f2dabf","span_id":"aaaaaaaaaaaaaaaa","parent":"",` +
+					`"traceparent":"00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01","tracestate":"",` +
+					`"start":"2026-10-18T00:00:00Z","end":"","duration_ms":0,"exit_code":0,"errors":[]}` + "\n",
+			},
+		},
 		{
-			Name: "otel-cli span (unconfigured, non-recording)",
+			Name: "otel-cli span end --end",
 			Config: FixtureConfig{
-				CliArgs: []string{"span", "--service", "main_test.go", "--name", "test-span-123", "--kind", "server"},
+				CliArgs: []string{"span", "end", "--sockdir", ".", "--end", "2026-10-18T00:00:05Z", "--output", "json"},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				CliOutput: `{"trace_id":"e39280f2980af3a8600ae98c74f2dabf","span_id":"aaaaaaaaaaaaaaaa","parent":"",` +
+					`"traceparent":"00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01","tracestate":"",` +
+					`"start":"2026-10-18T00:00:00Z","end":"2026-10-18T00:00:05Z","duration_ms":5000,"exit_code":0,"errors":[]}` + "\n",
 			},
+		},
+		{
+			Name:   "otel-cli span background (recording) changed with set",
+			Config: FixtureConfig{Foreground: true},
 			Expect: Results{Config: otelcli.DefaultConfig()},
 		},
 	},
-	// config file
+	// otel-cli span start/finish keep pending spans in a state file, nesting
+	// each start inside the one before it
 	{
 		{
-			Name: "load a json config file",
+			Name: "otel-cli span start (outer)",
 			Config: FixtureConfig{
-				CliArgs: []string{"status", "--config", "example-config.json"},
-				// this will take priority over the config
-				Env: map[string]string{
-					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+				CliArgs: []string{
+					"span", "start", "--state", "otel-cli-test-span-state.json",
+					"--name", "build", "--attrs", "step=1",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "aaaaaaaaaaaaaaaa",
+					"--tp-print",
 				},
-				TestTimeoutMs: 1000,
+				Env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
 			},
 			Expect: Results{
-				SpanCount: 1,
-				Diagnostics: otelcli.Diagnostics{
-					IsRecording:       true,
-					NumArgs:           3,
-					ParsedTimeoutMs:   1000,
-					Endpoint:          "*",
-					EndpointSource:    "*",
-					DetectedLocalhost: true,
-					Error:             "could not open file '/tmp/traceparent.txt' for read: open /tmp/traceparent.txt: no such file or directory",
-				},
-				Env: map[string]string{
-					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
-				},
-				Config: otelcli.DefaultConfig().
-					WithEndpoint("{{endpoint}}"). // tells the test framework to ignore/overwrite
-					WithTimeout("1s").
-					WithHeaders(map[string]string{"header1": "header1-value"}).
-					WithInsecure(true).
-					WithBlocking(false).
-					WithTlsNoVerify(true).
-					WithTlsCACert("/dev/null").
-					WithTlsClientKey("/dev/null").
-					WithTlsClientCert("/dev/null").
-					WithServiceName("configured_in_config_file").
-					WithSpanName("config_file_span").
-					WithKind("server").
-					WithAttributes(map[string]string{"attr1": "value1"}).
-					WithStatusCode("0").
-					WithStatusDescription("status description").
-					WithTraceparentCarrierFile("/tmp/traceparent.txt").
-					WithTraceparentIgnoreEnv(true).
-					WithTraceparentPrint(true).
-					WithTraceparentPrintExport(true).
-					WithTraceparentRequired(false).
-					WithBackgroundParentPollMs(100).
-					WithBackgroundSockdir("/tmp").
-					WithBackgroundWait(true).
-					WithSpanEndTime("now").
-					WithSpanEndTime("now").
-					WithEventName("config_file_event").
-					WithEventTime("now").
-					WithCfgFile("example-config.json").
-					WithVerbose(true).
-					WithFail(true),
+				Config: otelcli.DefaultConfig(),
+				CliOutput: "" +
+					"# trace id: e39280f2980af3a8600ae98c74f2dabf\n" +
+					"#  span id: aaaaaaaaaaaaaaaa\n" +
+					"TRACEPARENT=00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01\n",
 			},
 		},
-	},
-	// otel-cli with minimal config span sends a span that looks right
-	{
 		{
-			Name: "otel-cli span (recording)",
+			Name: "otel-cli span start (inner)",
 			Config: FixtureConfig{
-				CliArgs: []string{"span", "--service", "main_test.go", "--name", "test-span-123", "--kind", "server"},
-				Env: map[string]string{
-					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
+				CliArgs: []string{
+					"span", "start", "--state", "otel-cli-test-span-state.json",
+					"--name", "test", "--force-span-id", "bbbbbbbbbbbbbbbb", "--tp-print",
 				},
-				TestTimeoutMs: 1000,
+				Env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
 			},
 			Expect: Results{
-				Config:    otelcli.DefaultConfig(),
-				SpanCount: 1,
+				Config: otelcli.DefaultConfig(),
+				CliOutput: "" +
+					"# trace id: e39280f2980af3a8600ae98c74f2dabf\n" +
+					"#  span id: bbbbbbbbbbbbbbbb\n" +
+					"TRACEPARENT=00-e39280f2980af3a8600ae98c74f2dabf-bbbbbbbbbbbbbbbb-01\n",
 			},
 		},
-		// OTEL_RESOURCE_ATTRIBUTES and OTEL_CLI_SERVICE_NAME should get merged into
-		// the span resource attributes
 		{
-			Name: "otel-cli span with envvar service name and attributes (recording)",
+			Name: "otel-cli span event --state",
 			Config: FixtureConfig{
-				CliArgs: []string{"span", "--name", "test-span-service-name-and-attrs", "--kind", "server"},
-				Env: map[string]string{
-					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
-					"OTEL_CLI_SERVICE_NAME":       "test-service-abc123",
-					"OTEL_CLI_ATTRIBUTES":         "cafe=deadbeef,abc=123",
-					"OTEL_RESOURCE_ATTRIBUTES":    "foo.bar=baz",
-				},
-				TestTimeoutMs: 1000,
-			},
-			Expect: Results{
-				Config: otelcli.DefaultConfig(),
-				SpanData: map[string]string{
-					"span_id":            "*",
-					"trace_id":           "*",
-					"attributes":         "abc=123,cafe=deadbeef",
-					"service_attributes": "foo.bar=baz,service.name=test-service-abc123",
-				},
-				SpanCount: 1,
+				CliArgs: []string{"span", "event", "--state", "otel-cli-test-span-state.json", "--name", "compiled"},
 			},
+			Expect: Results{Config: otelcli.DefaultConfig()},
 		},
-		// OTEL_SERVICE_NAME
 		{
-			Name: "otel-cli span with envvar service name (recording)",
+			Name: "otel-cli span finish (inner)",
 			Config: FixtureConfig{
-				CliArgs: []string{"span"},
-				Env: map[string]string{
-					"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}",
-					"OTEL_SERVICE_NAME":           "test-service-123abc",
+				CliArgs: []string{
+					"span", "finish", "--state", "otel-cli-test-span-state.json",
+					"--attrs", "tests.failed=3", "--event", "cache miss",
+					"--status-code", "error", "--status-description", "tests failed",
 				},
-				TestTimeoutMs: 1000,
+				Env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
 			},
 			Expect: Results{
-				Config: otelcli.DefaultConfig(),
+				Config:     otelcli.DefaultConfig(),
+				SpanCount:  1,
+				EventCount: 2,
 				SpanData: map[string]string{
-					"service_attributes": "service.name=test-service-123abc",
+					"trace_id":           "e39280f2980af3a8600ae98c74f2dabf",
+					"span_id":            "bbbbbbbbbbbbbbbb",
+					"parent_span_id":     "aaaaaaaaaaaaaaaa",
+					"name":               "test",
+					"attributes":         "tests.failed=3",
+					"status_code":        "2",
+					"status_description": "tests failed",
 				},
-				SpanCount: 1,
 			},
 		},
-	},
-	// otel-cli span --print-tp actually prints
-	{
 		{
-			Name: "otel-cli span --print-tp (non-recording)",
+			Name: "otel-cli span finish (outer)",
 			Config: FixtureConfig{
-				CliArgs: []string{"span", "--tp-print"},
-				Env:     map[string]string{"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01"},
+				CliArgs: []string{"span", "finish", "--state", "otel-cli-test-span-state.json", "--attrs", "step=2"},
+				Env:     map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
 			},
 			Expect: Results{
-				Config: otelcli.DefaultConfig(),
-				CliOutput: "" + // empty so the text below can indent and line up
-					"# trace id: f6c109f48195b451c4def6ab32f47b61\n" +
-					"#  span id: a5d2a35f2483004e\n" +
-					"TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-01\n",
+				Config:    otelcli.DefaultConfig(),
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"span_id":        "aaaaaaaaaaaaaaaa",
+					"parent_span_id": "",
+					"name":           "build",
+					"attributes":     "step=2",
+				},
 			},
 		},
-	},
-	// otel-cli span --print-tp propagates traceparent even when not recording
-	{
 		{
-			Name: "otel-cli span --tp-print --tp-export (non-recording)",
+			Name: "otel-cli span finish with nothing pending",
 			Config: FixtureConfig{
-				CliArgs: []string{"span", "--tp-print", "--tp-export"},
-				Env: map[string]string{
-					"TRACEPARENT": "00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00",
-				},
+				CliArgs: []string{"span", "finish", "--state", "otel-cli-test-span-state.json", "--fail", "--verbose"},
+				Env:     map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "{{endpoint}}"},
 			},
 			Expect: Results{
-				Config: otelcli.DefaultConfig(),
-				CliOutput: "" +
-					"# trace id: f6c109f48195b451c4def6ab32f47b61\n" +
-					"#  span id: a5d2a35f2483004e\n" +
-					"export TRACEPARENT=00-f6c109f48195b451c4def6ab32f47b61-a5d2a35f2483004e-00\n",
+				Config:      otelcli.DefaultConfig(),
+				CliOutputRe: regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `),
+				CliOutput:   "no pending span to finish in \"otel-cli-test-span-state.json\", run otel-cli span start first\n",
+				ExitCode:    1,
 			},
 		},
 	},
@@ -1051,6 +1974,90 @@ var suites = []FixtureSuite{
 				SpanCount: 1,
 			},
 		},
+		{
+			Name: "otel-cli exec expands the other placeholders in args and --env only",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"exec", "--endpoint", "{{endpoint}}",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "023eee2731392b4d",
+					"--record-args", "template",
+					"--env", "SPAN=span={{span_id}}",
+					"--",
+					"/bin/sh", "-c", `echo "$0|$1|$2|$3|$4|$SPAN|$INHERITED"`,
+					"{{trace_id}}", "{{header:traceparent}}", "{{ baggage:team }}", `\{{span_id}}`, "{{.State}}"},
+				Env: map[string]string{
+					"BAGGAGE":   "team=obs%20cli,env=ci",
+					"INHERITED": "{{span_id}}",
+				},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				CliOutput: "e39280f2980af3a8600ae98c74f2dabf|traceparent: 00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01|" +
+					"obs cli|{{span_id}}|{{.State}}|span=023eee2731392b4d|{{span_id}}\n",
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"attributes": "/process.command_args=.*{{trace_id}}/",
+				},
+			},
+		},
+		{
+			Name: "otel-cli exec --remote passes the traceparent through a launcher",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"exec", "--endpoint", "{{endpoint}}",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf", "--force-span-id", "023eee2731392b4d",
+					"--remote", "--remote-launchers", "echo=ssh",
+					"--",
+					"/bin/echo", "-p", "2222", "deploy@web1", "uptime"},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				CliOutput: "-p 2222 deploy@web1 env TRACEPARENT=00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01 uptime\n",
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"attributes": "/exec.remote.launcher=ssh,net.peer.name=web1,net.peer.port=2222,.*process.command_args=/bin/echo,-p,2222,deploy@web1,uptime,/",
+				},
+			},
+		},
+		{
+			Name: "otel-cli exec names the span after the command when --name isn't set",
+			Config: FixtureConfig{
+				CliArgs: []string{
+					"exec", "--endpoint", "{{endpoint}}",
+					"--force-trace-id", "e39280f2980af3a8600ae98c74f2dabf",
+					"--",
+					"/bin/echo", "-n", "hello", "{{trace_id}}"},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				CliOutput: "hello e39280f2980af3a8600ae98c74f2dabf",
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"name": "echo hello {{trace_id}}",
+				},
+			},
+		},
+		{
+			Name: "otel-cli exec --name-template",
+			Config: FixtureConfig{
+				Env: map[string]string{
+					"JOB": "nightly",
+				},
+				CliArgs: []string{
+					"exec", "--endpoint", "{{endpoint}}",
+					"--name-template", "{{env:JOB}} {{command}} {{arg:2}}",
+					"--",
+					"/bin/echo", "-n", "hello"},
+			},
+			Expect: Results{
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}").WithNameTemplate("{{env:JOB}} {{command}} {{arg:2}}"),
+				CliOutput: "hello",
+				SpanCount: 1,
+				SpanData: map[string]string{
+					"name": "nightly echo hello",
+				},
+			},
+		},
 		{
 			Name: "otel-cli exec returns the {{traceparent}} tag unmodified with OTEL_CLI_EXEC_TP_DISABLE_INJECT",
 			Config: FixtureConfig{
@@ -1418,7 +2425,28 @@ var suites = []FixtureSuite{
 					"--command-timeout", "20ms",
 					"sleep", "1",
 				},
-				TestTimeoutMs: 50,
+				TestTimeoutMs: 500,
+			},
+			Expect: Results{
+				SpanCount: 1,
+				Config:    otelcli.DefaultConfig().WithEndpoint("{{endpoint}}"),
+				ExitCode:  2,
+				SpanData: map[string]string{
+					"status_code":        "2",
+					"status_description": "exec command failed: signal: terminated",
+				},
+			},
+		},
+		{
+			Name: "exec --command-timeout escalates to SIGKILL after --kill-grace",
+			Config: FixtureConfig{
+				CliArgs: []string{"exec",
+					"--endpoint", "{{endpoint}}",
+					"--command-timeout", "50ms",
+					"--kill-grace", "50ms",
+					"--", "/bin/sh", "-c", "trap '' TERM; sleep 1",
+				},
+				TestTimeoutMs: 500,
 			},
 			Expect: Results{
 				SpanCount: 1,
@@ -1429,6 +2457,22 @@ var suites = []FixtureSuite{
 					"status_description": "exec command failed: signal: killed",
 				},
 			},
+			CheckFuncs: []CheckFunc{
+				func(t *testing.T, f Fixture, r Results) {
+					got := []string{}
+					for _, e := range r.SpanEvents {
+						attrs := otlpclient.SpanAttributesToStringMap(&tracepb.Span{Attributes: e.Attributes})
+						got = append(got, e.Name+":"+attrs["signal.name"]+":"+attrs["signal.reason"])
+					}
+					want := "process.timeout::,process.signal:SIGTERM:timeout,process.signal:SIGKILL:escalation"
+					if strings.Join(got, ",") != want {
+						t.Errorf("[%s] expected exec timeout events %q but got %q", f.Name, want, strings.Join(got, ","))
+					}
+					if reason := otlpclient.SpanAttributesToStringMap(r.Span)["process.exit.reason"]; reason != "timeout" {
+						t.Errorf("[%s] expected process.exit.reason=timeout but got %q", f.Name, reason)
+					}
+				},
+			},
 		},
 		{
 			Name: "exec --command-timeout can run longer than --timeout",
@@ -1542,4 +2586,47 @@ var suites = []FixtureSuite{
 			},
 		},
 	},
+	// unix domain socket transport, for both gRPC and HTTP
+	{
+		{
+			Name: "span over a unix socket (grpc)",
+			Config: FixtureConfig{
+				ServerProtocol:   grpcProtocol,
+				ServerUnixSocket: true,
+				CliArgs:          []string{"span", "--endpoint", "unix://{{endpoint}}", "--name", "unixgrpc", "--fail", "--verbose"},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				SpanData: map[string]string{
+					"name": "unixgrpc",
+				},
+				ServerMeta: map[string]string{
+					"proto": "grpc",
+				},
+				SpanCount: 1,
+			},
+		},
+		{
+			Name: "span over a unix socket (http)",
+			Config: FixtureConfig{
+				ServerProtocol:   httpProtocol,
+				ServerUnixSocket: true,
+				CliArgs:          []string{"span", "--endpoint", "unix://{{endpoint}}", "--protocol", "http/protobuf", "--name", "unixhttp", "--fail", "--verbose"},
+			},
+			Expect: Results{
+				Config: otelcli.DefaultConfig(),
+				SpanData: map[string]string{
+					"name": "unixhttp",
+				},
+				ServerMeta: map[string]string{
+					"content-type": "application/x-protobuf",
+					"host":         "localhost",
+					"method":       "POST",
+					"proto":        "HTTP/1.1",
+					"uri":          "/v1/traces",
+				},
+				SpanCount: 1,
+			},
+		},
+	},
 }
@@ -15,6 +15,7 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -78,6 +79,10 @@ func TestOtelCli(t *testing.T) {
 	tlsData := generateTLSData(t)
 	defer tlsData.cleanup()
 
+	// the span state fixtures keep --state in the working directory
+	defer os.Remove("otel-cli-test-span-state.json")
+	defer os.Remove("otel-cli-test-span-state.json.lock")
+
 	for _, suite := range suites {
 		// a fixture can be backgrounded after starting it up for e.g. otel-cli span background
 		// a second fixture with the same description later in the list will "foreground" it
@@ -453,7 +458,9 @@ func runOtelCli(t *testing.T, fixture Fixture) (string, Results) {
 	// port :0 means randomly assigned port, which we copy into {{endpoint}}
 	var listener net.Listener
 	var err error
-	if fixture.Config.ServerTLSEnabled && fixture.Config.ServerProtocol == httpProtocol {
+	if fixture.Config.ServerUnixSocket {
+		listener, err = net.Listen("unix", filepath.Join(t.TempDir(), "otlp.sock"))
+	} else if fixture.Config.ServerTLSEnabled && fixture.Config.ServerProtocol == httpProtocol {
 		// HTTP needs a TLS listener; gRPC uses credentials passed to server
 		listener, err = tls.Listen("tcp", "localhost:0", tlsConf)
 	} else {
@@ -496,6 +503,9 @@ func runOtelCli(t *testing.T, fixture Fixture) (string, Results) {
 	var cliOut bytes.Buffer
 	statusCmd.Stdout = &cliOut
 	statusCmd.Stderr = &cliOut
+	if fixture.Config.Stdin != "" {
+		statusCmd.Stdin = strings.NewReader(fixture.Config.Stdin)
+	}
 
 	err = statusCmd.Start()
 	if err != nil {
@@ -39,7 +39,8 @@ func DefaultConfig() Config {
 		TlsClientKey:                 "",
 		TlsClientCert:                "",
 		ServiceName:                  "otel-cli",
-		SpanName:                     "todo-generate-default-span-names",
+		SpanName:                     "",
+		NameTemplate:                 "",
 		Kind:                         "client",
 		ForceTraceId:                 "",
 		ForceSpanId:                  "",
@@ -52,19 +53,62 @@ func DefaultConfig() Config {
 		TraceparentRequired:          false,
 		BackgroundParentPollMs:       10,
 		BackgroundSockdir:            "",
+		BackgroundName:               "",
 		BackgroundWait:               false,
 		BackgroundSkipParentPidCheck: false,
+		BackgroundSendSteps:          false,
+		BackgroundStep:               "",
+		BackgroundStepParent:         "",
+		BackgroundRemoveAttrs:        []string{},
+		BackgroundLinks:              []string{},
+		SpanState:                    "",
+		SpanFinishEvents:             []string{},
 		ExecCommandTimeout:           "",
+		ExecKillGrace:                "5s",
 		ExecTpDisableInject:          false,
+		ExecRecordArgs:               "expanded",
+		ExecEnv:                      []string{},
+		ExecRemote:                   false,
+		ExecRemoteBaggage:            false,
+		ExecRemoteLaunchers:          map[string]string{},
+		ExecCapture:                  false,
+		ExecCaptureTailLines:         10,
+		ExecCaptureTailBytes:         4096,
+		ExecCaptureRedact:            defaultExecCaptureRedact,
+		ExecSampleInterval:           "",
+		ExecStatusMap:                map[string]string{},
+		ExecRetries:                  0,
+		ExecRetryDelay:               "1s",
+		ExecRetryMaxDelay:            "30s",
+		ExecRetryOn:                  "",
+		ExecSideChannel:              "",
+		ExecRelay:                    "",
+		ExecRelayListen:              "127.0.0.1:0",
+		ExecPty:                      false,
+		ExecLogs:                     false,
+		ExecLogsInterval:             "",
+		ExecLogsErrorMatch:           defaultExecLogsErrorMatch,
+		ExecExportEnv:                false,
+		ExecExportEnvDeny:            defaultExecExportEnvDeny,
+		PipeMatch:                    "",
+		PipeEventName:                "line",
+		PipeSpanStart:                []string{},
+		PipeSpanEnd:                  []string{},
+		PipeMaxEvents:                1000,
+		ParallelJobs:                 0,
+		ParallelCommand:              "",
+		ParallelFailFast:             false,
 		StatusCanaryCount:            1,
 		StatusCanaryInterval:         "",
+		ServerZipkinEndpoint:         "",
 		SpanStartTime:                "now",
 		SpanEndTime:                  "now",
-		EventName:                    "todo-generate-default-event-names",
+		EventName:                    "",
 		EventTime:                    "now",
 		CfgFile:                      "",
 		Verbose:                      false,
 		Fail:                         false,
+		Output:                       "text",
 		StatusCode:                   "unset",
 		StatusDescription:            "",
 		Version:                      "unset",
@@ -76,6 +120,7 @@ func DefaultConfig() Config {
 type Config struct {
 	Endpoint       string            `json:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
 	TracesEndpoint string            `json:"traces_endpoint" env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"`
+	LogsEndpoint   string            `json:"logs_endpoint" env:"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"`
 	Protocol       string            `json:"protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL,OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"`
 	Timeout        string            `json:"timeout" env:"OTEL_EXPORTER_OTLP_TIMEOUT,OTEL_EXPORTER_OTLP_TRACES_TIMEOUT"`
 	Headers        map[string]string `json:"otlp_headers" env:"OTEL_EXPORTER_OTLP_HEADERS"` // TODO: needs json marshaler hook to mask tokens
@@ -90,6 +135,7 @@ type Config struct {
 
 	ServiceName       string            `json:"service_name" env:"OTEL_CLI_SERVICE_NAME,OTEL_SERVICE_NAME"`
 	SpanName          string            `json:"span_name" env:"OTEL_CLI_SPAN_NAME"`
+	NameTemplate      string            `json:"name_template" env:"OTEL_CLI_NAME_TEMPLATE"`
 	Kind              string            `json:"span_kind" env:"OTEL_CLI_TRACE_KIND"`
 	Attributes        map[string]string `json:"span_attributes" env:"OTEL_CLI_ATTRIBUTES"`
 	StatusCode        string            `json:"span_status_code" env:"OTEL_CLI_STATUS_CODE"`
@@ -106,15 +152,73 @@ type Config struct {
 
 	BackgroundParentPollMs       int    `json:"background_parent_poll_ms" env:""`
 	BackgroundSockdir            string `json:"background_socket_directory" env:""`
+	BackgroundName               string `json:"background_name" env:""`
 	BackgroundWait               bool   `json:"background_wait" env:""`
 	BackgroundSkipParentPidCheck bool   `json:"background_skip_parent_pid_check"`
+	BackgroundSendSteps          bool   `json:"background_send_steps" env:""`
+	BackgroundStep               string `json:"background_step" env:""`
+	BackgroundStepParent         string `json:"background_step_parent" env:""`
+
+	BackgroundRemoveAttrs []string `json:"background_remove_attributes" env:""`
+	BackgroundLinks       []string `json:"background_links" env:""`
+
+	SpanState        string   `json:"span_state" env:"OTEL_CLI_SPAN_STATE"`
+	SpanFinishEvents []string `json:"span_finish_events" env:""`
 
 	ExecCommandTimeout  string `json:"exec_command_timeout" env:"OTEL_CLI_EXEC_CMD_TIMEOUT"`
+	ExecKillGrace       string `json:"exec_kill_grace" env:"OTEL_CLI_EXEC_KILL_GRACE"`
 	ExecTpDisableInject bool   `json:"exec_tp_disable_inject" env:"OTEL_CLI_EXEC_TP_DISABLE_INJECT"`
+	ExecRecordArgs      string `json:"exec_record_args" env:"OTEL_CLI_EXEC_RECORD_ARGS"`
+
+	ExecRemote          bool              `json:"exec_remote" env:"OTEL_CLI_EXEC_REMOTE"`
+	ExecRemoteBaggage   bool              `json:"exec_remote_baggage" env:"OTEL_CLI_EXEC_REMOTE_BAGGAGE"`
+	ExecRemoteLaunchers map[string]string `json:"exec_remote_launchers" env:"OTEL_CLI_EXEC_REMOTE_LAUNCHERS"`
+
+	ExecCapture          bool   `json:"exec_capture" env:"OTEL_CLI_EXEC_CAPTURE"`
+	ExecCaptureTailLines int    `json:"exec_capture_tail_lines" env:"OTEL_CLI_EXEC_CAPTURE_TAIL_LINES"`
+	ExecCaptureTailBytes int    `json:"exec_capture_tail_bytes" env:"OTEL_CLI_EXEC_CAPTURE_TAIL_BYTES"`
+	ExecCaptureRedact    string `json:"exec_capture_redact" env:"OTEL_CLI_EXEC_CAPTURE_REDACT"`
+	ExecSampleInterval   string `json:"exec_sample_interval" env:"OTEL_CLI_EXEC_SAMPLE_INTERVAL"`
+
+	ExecStatusMap map[string]string `json:"exec_status_map" env:"OTEL_CLI_EXEC_STATUS_MAP"`
+
+	ExecRetries       int    `json:"exec_retries" env:"OTEL_CLI_EXEC_RETRIES"`
+	ExecRetryDelay    string `json:"exec_retry_delay" env:"OTEL_CLI_EXEC_RETRY_DELAY"`
+	ExecRetryMaxDelay string `json:"exec_retry_max_delay" env:"OTEL_CLI_EXEC_RETRY_MAX_DELAY"`
+	ExecRetryOn       string `json:"exec_retry_on" env:"OTEL_CLI_EXEC_RETRY_ON"`
+
+	ExecSideChannel string `json:"exec_side_channel" env:"OTEL_CLI_EXEC_SIDE_CHANNEL"`
+
+	ExecRelay       string `json:"exec_relay" env:"OTEL_CLI_EXEC_RELAY"`
+	ExecRelayListen string `json:"exec_relay_listen" env:"OTEL_CLI_EXEC_RELAY_LISTEN"`
+
+	ExecPty bool `json:"exec_pty" env:"OTEL_CLI_EXEC_PTY"`
+
+	ExecLogs           bool   `json:"exec_logs" env:"OTEL_CLI_EXEC_LOGS"`
+	ExecLogsInterval   string `json:"exec_logs_interval" env:"OTEL_CLI_EXEC_LOGS_INTERVAL"`
+	ExecLogsErrorMatch string `json:"exec_logs_error_match" env:"OTEL_CLI_EXEC_LOGS_ERROR_MATCH"`
+
+	ExecExportEnv     bool   `json:"exec_export_env" env:"OTEL_CLI_EXEC_EXPORT_ENV"`
+	ExecExportEnvDeny string `json:"exec_export_env_deny" env:"OTEL_CLI_EXEC_EXPORT_ENV_DENY"`
+
+	// NAME=VALUE pairs for the command's environment, with placeholders expanded
+	ExecEnv []string `json:"exec_env" env:""`
+
+	PipeMatch     string   `json:"pipe_match" env:"OTEL_CLI_PIPE_MATCH"`
+	PipeEventName string   `json:"pipe_event_name" env:"OTEL_CLI_PIPE_EVENT_NAME"`
+	PipeSpanStart []string `json:"pipe_span_start" env:""`
+	PipeSpanEnd   []string `json:"pipe_span_end" env:""`
+	PipeMaxEvents int      `json:"pipe_max_events" env:"OTEL_CLI_PIPE_MAX_EVENTS"`
+
+	ParallelJobs     int    `json:"parallel_jobs" env:"OTEL_CLI_PARALLEL_JOBS"`
+	ParallelCommand  string `json:"parallel_command" env:"OTEL_CLI_PARALLEL_COMMAND"`
+	ParallelFailFast bool   `json:"parallel_fail_fast" env:"OTEL_CLI_PARALLEL_FAIL_FAST"`
 
 	StatusCanaryCount    int    `json:"status_canary_count"`
 	StatusCanaryInterval string `json:"status_canary_interval"`
 
+	ServerZipkinEndpoint string `json:"server_zipkin_endpoint" env:""`
+
 	SpanStartTime string `json:"span_start_time" env:""`
 	SpanEndTime   string `json:"span_end_time" env:""`
 	EventName     string `json:"event_name" env:""`
@@ -123,6 +227,7 @@ type Config struct {
 	CfgFile string `json:"config_file" env:"OTEL_CLI_CONFIG_FILE"`
 	Verbose bool   `json:"verbose" env:"OTEL_CLI_VERBOSE"`
 	Fail    bool   `json:"fail" env:"OTEL_CLI_FAIL"`
+	Output  string `json:"output" env:"OTEL_CLI_OUTPUT"`
 
 	// not exported, used to get data from cobra to otlpclient internals
 	Version string `json:"-"`
@@ -219,6 +324,7 @@ func (c Config) ToStringMap() map[string]string {
 		"tls_client_cert":             c.TlsClientCert,
 		"service_name":                c.ServiceName,
 		"span_name":                   c.SpanName,
+		"name_template":               c.NameTemplate,
 		"span_kind":                   c.Kind,
 		"span_attributes":             flattenStringMap(c.Attributes, "{}"),
 		"span_status_code":            c.StatusCode,
@@ -230,16 +336,58 @@ func (c Config) ToStringMap() map[string]string {
 		"traceparent_required":        strconv.FormatBool(c.TraceparentRequired),
 		"background_parent_poll_ms":   strconv.Itoa(c.BackgroundParentPollMs),
 		"background_socket_directory": c.BackgroundSockdir,
+		"background_name":             c.BackgroundName,
 		"background_wait":             strconv.FormatBool(c.BackgroundWait),
 		"background_skip_pid_check":   strconv.FormatBool(c.BackgroundSkipParentPidCheck),
+		"background_send_steps":       strconv.FormatBool(c.BackgroundSendSteps),
+		"background_step":             c.BackgroundStep,
+		"background_step_parent":      c.BackgroundStepParent,
+		"background_remove_attrs":     strings.Join(c.BackgroundRemoveAttrs, ","),
+		"background_links":            strings.Join(c.BackgroundLinks, ","),
+		"span_state":                  c.SpanState,
+		"span_finish_events":          strings.Join(c.SpanFinishEvents, ","),
 		"exec_command_timeout":        c.ExecCommandTimeout,
+		"exec_kill_grace":             c.ExecKillGrace,
 		"exec_tp_disable_inject":      strconv.FormatBool(c.ExecTpDisableInject),
+		"exec_record_args":            c.ExecRecordArgs,
+		"exec_remote":                 strconv.FormatBool(c.ExecRemote),
+		"exec_remote_baggage":         strconv.FormatBool(c.ExecRemoteBaggage),
+		"exec_remote_launchers":       flattenStringMap(c.ExecRemoteLaunchers, "{}"),
+		"exec_capture":                strconv.FormatBool(c.ExecCapture),
+		"exec_capture_tail_lines":     strconv.Itoa(c.ExecCaptureTailLines),
+		"exec_capture_tail_bytes":     strconv.Itoa(c.ExecCaptureTailBytes),
+		"exec_capture_redact":         c.ExecCaptureRedact,
+		"exec_sample_interval":        c.ExecSampleInterval,
+		"exec_status_map":             flattenStringMap(c.ExecStatusMap, "{}"),
+		"exec_retries":                strconv.Itoa(c.ExecRetries),
+		"exec_retry_delay":            c.ExecRetryDelay,
+		"exec_retry_max_delay":        c.ExecRetryMaxDelay,
+		"exec_retry_on":               c.ExecRetryOn,
+		"exec_side_channel":           c.ExecSideChannel,
+		"exec_relay":                  c.ExecRelay,
+		"exec_relay_listen":           c.ExecRelayListen,
+		"exec_pty":                    strconv.FormatBool(c.ExecPty),
+		"exec_logs":                   strconv.FormatBool(c.ExecLogs),
+		"exec_logs_interval":          c.ExecLogsInterval,
+		"exec_logs_error_match":       c.ExecLogsErrorMatch,
+		"exec_export_env":             strconv.FormatBool(c.ExecExportEnv),
+		"exec_export_env_deny":        c.ExecExportEnvDeny,
+		"exec_env":                    strings.Join(c.ExecEnv, ","),
+		"pipe_match":                  c.PipeMatch,
+		"pipe_event_name":             c.PipeEventName,
+		"pipe_span_start":             strings.Join(c.PipeSpanStart, ","),
+		"pipe_span_end":               strings.Join(c.PipeSpanEnd, ","),
+		"pipe_max_events":             strconv.Itoa(c.PipeMaxEvents),
+		"parallel_jobs":               strconv.Itoa(c.ParallelJobs),
+		"parallel_command":            c.ParallelCommand,
+		"parallel_fail_fast":          strconv.FormatBool(c.ParallelFailFast),
 		"span_start_time":             c.SpanStartTime,
 		"span_end_time":               c.SpanEndTime,
 		"event_name":                  c.EventName,
 		"event_time":                  c.EventTime,
 		"config_file":                 c.CfgFile,
 		"verbose":                     strconv.FormatBool(c.Verbose),
+		"output":                      c.Output,
 	}
 }
 
@@ -274,6 +422,43 @@ func (c Config) ParseExecCommandTimeout() time.Duration {
 	return out
 }
 
+// ParseExecKillGrace parses the --kill-grace string value to a time.Duration.
+func (c Config) ParseExecKillGrace() time.Duration {
+	out, err := parseDuration(c.ExecKillGrace)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// ParseExecSampleInterval parses the --sample-interval string value to a time.Duration.
+// When unspecified or 0, the child process is not sampled.
+func (c Config) ParseExecSampleInterval() time.Duration {
+	out, err := parseDuration(c.ExecSampleInterval)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// ParseExecRetryDelay parses the --retry-delay string value to a time.Duration.
+func (c Config) ParseExecRetryDelay() time.Duration {
+	out, err := parseDuration(c.ExecRetryDelay)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// ParseExecRetryMaxDelay parses the --retry-max-delay string value to a time.Duration.
+func (c Config) ParseExecRetryMaxDelay() time.Duration {
+	out, err := parseDuration(c.ExecRetryMaxDelay)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+// ParseExecLogsInterval parses the --logs-interval string value to a time.Duration.
+// When unspecified or 0, log records are only sent after the command exits.
+func (c Config) ParseExecLogsInterval() time.Duration {
+	out, err := parseDuration(c.ExecLogsInterval)
+	c.SoftFailIfErr(err)
+	return out
+}
+
 // ParseStatusCanaryInterval parses the --canary-interval string value to a time.Duration.
 func (c Config) ParseStatusCanaryInterval() time.Duration {
 	out, err := parseDuration(c.StatusCanaryInterval)
@@ -303,13 +488,51 @@ func parseDuration(d string) (time.Duration, error) {
 // (e.g. bare host:port for gRPC) and then parses as a URL.
 // https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md#endpoint-urls-for-otlphttp
 func (config Config) ParseEndpoint() (*url.URL, string) {
+	epUrl, source := config.parseSignalEndpoint(config.TracesEndpoint, "/v1/traces")
+
+	Diag.EndpointSource = source
+	Diag.Endpoint = epUrl.String()
+	return epUrl, source
+}
+
+// ParseLogsEndpoint is ParseEndpoint for the logs signal. When only the
+// traces endpoint is set, logs go to the same place with /v1/traces
+// swapped for /v1/logs, and the source is "traces".
+func (config Config) ParseLogsEndpoint() (*url.URL, string) {
+	return config.parseDerivedEndpoint(config.LogsEndpoint, "/v1/logs")
+}
+
+// ParseMetricsEndpoint is ParseLogsEndpoint for metrics, which otel-cli only
+// sends when relaying, so there is no metrics-specific endpoint setting.
+func (config Config) ParseMetricsEndpoint() (*url.URL, string) {
+	return config.parseDerivedEndpoint("", "/v1/metrics")
+}
+
+// parseDerivedEndpoint does the work for ParseLogsEndpoint and
+// ParseMetricsEndpoint, falling back to the traces endpoint with its
+// path swapped for signalPath.
+func (config Config) parseDerivedEndpoint(signalEndpoint, signalPath string) (*url.URL, string) {
+	if signalEndpoint == "" && config.Endpoint == "" && config.TracesEndpoint != "" {
+		epUrl, _ := config.parseSignalEndpoint(config.TracesEndpoint, "/v1/traces")
+		if strings.HasPrefix(epUrl.Scheme, "http") && strings.HasSuffix(epUrl.Path, "/v1/traces") {
+			epUrl.Path = strings.TrimSuffix(epUrl.Path, "/v1/traces") + signalPath
+		}
+		return epUrl, "traces"
+	}
+
+	return config.parseSignalEndpoint(signalEndpoint, signalPath)
+}
+
+// parseSignalEndpoint does the work for ParseEndpoint and ParseLogsEndpoint,
+// with signalPath being the default path for the signal on OTLP/HTTP.
+func (config Config) parseSignalEndpoint(signalEndpoint, signalPath string) (*url.URL, string) {
 	var endpoint, source string
 	var epUrl *url.URL
 	var err error
 
 	// signal-specific configs get precedence over general endpoint per OTel spec
-	if config.TracesEndpoint != "" {
-		endpoint = config.TracesEndpoint
+	if signalEndpoint != "" {
+		endpoint = signalEndpoint
 		source = "signal"
 	} else if config.Endpoint != "" {
 		endpoint = config.Endpoint
@@ -328,7 +551,8 @@ func (config Config) ParseEndpoint() (*url.URL, string) {
 	} else if len(parts) > 1 { // could be URI or host:port
 		// actual URIs
 		// grpc:// is only an otel-cli thing, maybe should drop it?
-		if parts[0] == "grpc" || parts[0] == "http" || parts[0] == "https" {
+		// unix:///path/to.sock is a socket path, gRPC unless --protocol says http
+		if parts[0] == "grpc" || parts[0] == "http" || parts[0] == "https" || parts[0] == "unix" {
 			epUrl, err = url.Parse(endpoint)
 			if err != nil {
 				config.SoftFail("error parsing provided %s URI '%s': %s", source, endpoint, err)
@@ -342,14 +566,12 @@ func (config Config) ParseEndpoint() (*url.URL, string) {
 		}
 	}
 
-	// Per spec, /v1/traces is the default, appended to any url passed
-	// to the general endpoint
-	if strings.HasPrefix(epUrl.Scheme, "http") && source != "signal" && !strings.HasSuffix(epUrl.Path, "/v1/traces") {
-		epUrl.Path = path.Join(epUrl.Path, "/v1/traces")
+	// Per spec, the signal path (e.g. /v1/traces) is the default, appended
+	// to any url passed to the general endpoint
+	if strings.HasPrefix(epUrl.Scheme, "http") && source != "signal" && !strings.HasSuffix(epUrl.Path, signalPath) {
+		epUrl.Path = path.Join(epUrl.Path, signalPath)
 	}
 
-	Diag.EndpointSource = source
-	Diag.Endpoint = epUrl.String()
 	return epUrl, source
 }
 
@@ -438,9 +660,9 @@ func parseCkvStringMap(in string) (map[string]string, error) {
 
 	out := make(map[string]string)
 	for _, pair := range pairs {
-		parts := strings.SplitN(pair, "=", 2)
-		if parts[0] != "" && parts[1] != "" {
-			out[parts[0]] = parts[1]
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && key != "" && value != "" {
+			out[key] = value
 		} else {
 			return map[string]string{}, fmt.Errorf("kv pair %s must be in key=value format", pair)
 		}
@@ -537,6 +759,24 @@ func (c Config) WithEndpoint(with string) Config {
 	return c
 }
 
+// GetLogsEndpoint returns the parsed endpoint for the logs signal.
+func (c Config) GetLogsEndpoint() *url.URL {
+	ep, _ := c.ParseLogsEndpoint()
+	return ep
+}
+
+// GetMetricsEndpoint returns the parsed endpoint for the metrics signal.
+func (c Config) GetMetricsEndpoint() *url.URL {
+	ep, _ := c.ParseMetricsEndpoint()
+	return ep
+}
+
+// WithLogsEndpoint returns the config with LogsEndpoint set to the provided value.
+func (c Config) WithLogsEndpoint(with string) Config {
+	c.LogsEndpoint = with
+	return c
+}
+
 // WithTracesEndpoint returns the config with TracesEndpoint set to the provided value.
 func (c Config) WithTracesEndpoint(with string) Config {
 	c.TracesEndpoint = with
@@ -624,6 +864,12 @@ func (c Config) WithSpanName(with string) Config {
 	return c
 }
 
+// WithNameTemplate returns the config with NameTemplate set to the provided value.
+func (c Config) WithNameTemplate(with string) Config {
+	c.NameTemplate = with
+	return c
+}
+
 // WithKind returns the config with Kind set to the provided value.
 func (c Config) WithKind(with string) Config {
 	c.Kind = with
@@ -690,6 +936,12 @@ func (c Config) WithBackgroundSockdir(with string) Config {
 	return c
 }
 
+// WithBackgroundName returns the config with BackgroundName set to the provided value.
+func (c Config) WithBackgroundName(with string) Config {
+	c.BackgroundName = with
+	return c
+}
+
 // WithBackgroundWait returns the config with BackgroundWait set to the provided value.
 func (c Config) WithBackgroundWait(with bool) Config {
 	c.BackgroundWait = with
@@ -714,6 +966,12 @@ func (c Config) WithStatusCanaryInterval(with string) Config {
 	return c
 }
 
+// WithServerZipkinEndpoint returns the config with ServerZipkinEndpoint set to the provided value.
+func (c Config) WithServerZipkinEndpoint(with string) Config {
+	c.ServerZipkinEndpoint = with
+	return c
+}
+
 // WithSpanStartTime returns the config with SpanStartTime set to the provided value.
 func (c Config) WithSpanStartTime(with string) Config {
 	c.SpanStartTime = with
@@ -750,6 +1008,12 @@ func (c Config) WithVerbose(with bool) Config {
 	return c
 }
 
+// WithOutput returns the config with Output set to the provided value.
+func (c Config) WithOutput(with string) Config {
+	c.Output = with
+	return c
+}
+
 // WithFail returns the config with Fail set to the provided value.
 func (c Config) WithFail(with bool) Config {
 	c.Fail = with
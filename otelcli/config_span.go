@@ -2,6 +2,7 @@ package otelcli
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -20,7 +21,7 @@ func (c Config) NewProtobufSpan() *tracepb.Span {
 		span.TraceId = otlpclient.GenerateTraceId()
 		span.SpanId = otlpclient.GenerateSpanId()
 	}
-	span.Name = c.SpanName
+	span.Name = c.GetSpanName(parentCommandLine())
 	span.Kind = otlpclient.SpanKindStringToInt(c.Kind)
 	span.Attributes = otlpclient.StringMapAttrsToProtobuf(c.Attributes)
 
@@ -116,8 +117,9 @@ func (c Config) LoadTraceparent() traceparent.Traceparent {
 }
 
 // PropagateTraceparent saves the traceparent to file if necessary, then prints
-// span info to the console according to command-line args.
-func (c Config) PropagateTraceparent(span *tracepb.Span, target io.Writer) {
+// span info to the console according to command-line args, including any
+// errors saved in ctx with --output json or shell.
+func (c Config) PropagateTraceparent(ctx context.Context, span *tracepb.Span, target io.Writer) {
 	var tp traceparent.Traceparent
 	if c.GetIsRecording() {
 		tp = otlpclient.TraceparentFromProtobufSpan(span, c.GetIsRecording())
@@ -131,9 +133,8 @@ func (c Config) PropagateTraceparent(span *tracepb.Span, target io.Writer) {
 		c.SoftFailIfErr(err)
 	}
 
-	if c.TraceparentPrint {
-		tp.Fprint(target, c.TraceparentPrintExport)
-	}
+	out := newSpanOutput(ctx, c, tp, span.ParentSpanId, span.StartTimeUnixNano, span.EndTimeUnixNano)
+	c.printSpanOutput(out, target)
 }
 
 // parseHex parses hex into a []byte of length provided. Errors if the input is
@@ -2,6 +2,7 @@ package otelcli
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -26,7 +27,7 @@ func TestPropagateTraceparent(t *testing.T) {
 	span.SpanId, _ = hex.DecodeString(sid)
 
 	buf := new(bytes.Buffer)
-	config.PropagateTraceparent(span, buf)
+	config.PropagateTraceparent(context.Background(), span, buf)
 	if buf.Len() != 0 {
 		t.Errorf("nothing was supposed to be written but %d bytes were", buf.Len())
 	}
@@ -34,7 +35,7 @@ func TestPropagateTraceparent(t *testing.T) {
 	config.TraceparentPrint = true
 	config.TraceparentPrintExport = true
 	buf = new(bytes.Buffer)
-	config.PropagateTraceparent(span, buf)
+	config.PropagateTraceparent(context.Background(), span, buf)
 	if buf.Len() == 0 {
 		t.Error("expected more than zero bytes but got none")
 	}
@@ -70,6 +70,12 @@ func TestParseCkvStringMap(t *testing.T) {
 	if diff := cmp.Diff(expect, got); diff != "" {
 		t.Errorf("maps didn't match (-want +got):\n%s", diff)
 	}
+
+	for _, bad := range []string{"novalue", "a=b,c", "=nokey", "empty="} {
+		if _, err := parseCkvStringMap(bad); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
 }
 
 func TestParseTime(t *testing.T) {
@@ -227,6 +233,18 @@ func TestParseEndpoint(t *testing.T) {
 			wantEndpoint: "http://localhost",
 			wantSource:   "signal",
 		},
+		// unix socket, general, path is the socket file and should not be modified
+		{
+			config:       DefaultConfig().WithEndpoint("unix:///tmp/otlp.sock"),
+			wantEndpoint: "unix:///tmp/otlp.sock",
+			wantSource:   "general",
+		},
+		// unix socket over HTTP, should not get /v1/traces appended to the socket path
+		{
+			config:       DefaultConfig().WithEndpoint("unix:///tmp/otlp.sock").WithProtocol("http/protobuf"),
+			wantEndpoint: "unix:///tmp/otlp.sock",
+			wantSource:   "general",
+		},
 	} {
 		u, src := tc.config.ParseEndpoint()
 
@@ -240,6 +258,49 @@ func TestParseEndpoint(t *testing.T) {
 	}
 }
 
+func TestParseLogsEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		config       Config
+		wantEndpoint string
+		wantSource   string
+	}{
+		// general endpoint gets /v1/logs instead of /v1/traces
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318"),
+			wantEndpoint: "http://localhost:4318/v1/logs",
+			wantSource:   "general",
+		},
+		// gRPC is the same endpoint for every signal
+		{
+			config:       DefaultConfig().WithEndpoint("localhost:4317"),
+			wantEndpoint: "grpc://localhost:4317",
+			wantSource:   "general",
+		},
+		// logs signal endpoint comes through unmodified
+		{
+			config:       DefaultConfig().WithEndpoint("http://localhost:4318").WithLogsEndpoint("http://logs:9999/ingest"),
+			wantEndpoint: "http://logs:9999/ingest",
+			wantSource:   "signal",
+		},
+		// only a traces endpoint, logs go next to it
+		{
+			config:       DefaultConfig().WithTracesEndpoint("https://collector:4318/otlp/v1/traces"),
+			wantEndpoint: "https://collector:4318/otlp/v1/logs",
+			wantSource:   "traces",
+		},
+	} {
+		u, src := tc.config.ParseLogsEndpoint()
+
+		if u.String() != tc.wantEndpoint {
+			t.Errorf("Expected endpoint %q but got %q", tc.wantEndpoint, u.String())
+		}
+
+		if src != tc.wantSource {
+			t.Errorf("Expected source %q for test url %q but got %q", tc.wantSource, u.String(), src)
+		}
+	}
+}
+
 func TestWithEndpoint(t *testing.T) {
 	if DefaultConfig().WithEndpoint("foobar").Endpoint != "foobar" {
 		t.Fail()
@@ -302,6 +363,11 @@ func TestWithSpanName(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithNameTemplate(t *testing.T) {
+	if DefaultConfig().WithNameTemplate("{{command}}").NameTemplate != "{{command}}" {
+		t.Fail()
+	}
+}
 func TestWithKind(t *testing.T) {
 	if DefaultConfig().WithKind("producer").Kind != "producer" {
 		t.Fail()
@@ -370,6 +436,11 @@ func TestWithBackgroundSockdir(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithBackgroundName(t *testing.T) {
+	if DefaultConfig().WithBackgroundName("build").BackgroundName != "build" {
+		t.Fail()
+	}
+}
 func TestWithBackgroundWait(t *testing.T) {
 	if DefaultConfig().WithBackgroundWait(true).BackgroundWait != true {
 		t.Fail()
@@ -385,6 +456,11 @@ func TestWithStatusCanaryInterval(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestWithServerZipkinEndpoint(t *testing.T) {
+	if DefaultConfig().WithServerZipkinEndpoint("localhost:9411").ServerZipkinEndpoint != "localhost:9411" {
+		t.Fail()
+	}
+}
 func TestWithSpanStartTime(t *testing.T) {
 	if DefaultConfig().WithSpanStartTime("foobar").SpanStartTime != "foobar" {
 		t.Fail()
@@ -60,6 +60,11 @@ func (config Config) GetTlsConfig() *tls.Config {
 func (c Config) GetInsecure() bool {
 	endpointURL := c.GetEndpoint()
 
+	// unix sockets have no hostname to look up and TLS over them makes no sense
+	if endpointURL.Scheme == "unix" {
+		return true
+	}
+
 	isLoopback, err := isLoopbackAddr(endpointURL)
 	c.SoftFailIfErr(err)
 
@@ -70,7 +75,7 @@ func (c Config) GetInsecure() bool {
 	// an obvious "localhost", "127.0.0.x", or "::1" address.
 	if c.Insecure || (isLoopback && endpointURL.Scheme != "https") {
 		return true
-	} else if endpointURL.Scheme == "http" || endpointURL.Scheme == "unix" {
+	} else if endpointURL.Scheme == "http" {
 		return true
 	}
 
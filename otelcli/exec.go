@@ -2,10 +2,9 @@ package otelcli
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"os/signal"
 	"os/user"
 	"strings"
 	"time"
@@ -48,11 +47,193 @@ otel-cli exec -s "outer span" 'otel-cli exec -s "inner span" sleep 1'`,
 		"timeout for the child process, when 0 otel-cli will wait forever",
 	)
 
+	cmd.Flags().StringVar(
+		&config.ExecKillGrace,
+		"kill-grace",
+		defaults.ExecKillGrace,
+		"after --command-timeout sends SIGTERM, how long to wait before sending SIGKILL",
+	)
+
 	cmd.Flags().BoolVar(
 		&config.ExecTpDisableInject,
 		"tp-disable-inject",
 		defaults.ExecTpDisableInject,
-		"disable automatically replacing {{traceparent}} with a traceparent",
+		"disable replacing {{traceparent}}, {{trace_id}}, and the other placeholders in the command's args and --env",
+	)
+
+	cmd.Flags().StringArrayVar(
+		&config.ExecEnv,
+		"env",
+		defaults.ExecEnv,
+		"set NAME=VALUE in the command's environment with placeholders in VALUE replaced, can be repeated",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRecordArgs,
+		"record-args",
+		defaults.ExecRecordArgs,
+		"'expanded' or 'template', record the command's args with placeholders replaced or as given",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecRemote,
+		"remote",
+		defaults.ExecRemote,
+		"when the command is ssh, docker, or kubectl, rewrite it to pass TRACEPARENT to the remote command",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecRemoteBaggage,
+		"remote-baggage",
+		defaults.ExecRemoteBaggage,
+		"with --remote, pass BAGGAGE along too",
+	)
+
+	cmd.Flags().StringToStringVar(
+		&config.ExecRemoteLaunchers,
+		"remote-launchers",
+		defaults.ExecRemoteLaunchers,
+		"with --remote, more commands to treat as ssh, docker, or kubectl, e.g. jump=ssh,dockerx=docker",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecCapture,
+		"capture",
+		defaults.ExecCapture,
+		"tee the child's stdout/stderr to record byte & line counts, and the tail of stderr on failure",
+	)
+
+	cmd.Flags().IntVar(
+		&config.ExecCaptureTailLines,
+		"capture-tail-lines",
+		defaults.ExecCaptureTailLines,
+		"with --capture, how many lines of stderr to attach to the span when the command fails",
+	)
+
+	cmd.Flags().IntVar(
+		&config.ExecCaptureTailBytes,
+		"capture-tail-bytes",
+		defaults.ExecCaptureTailBytes,
+		"with --capture, the maximum size of the stderr tail attached to the span",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecCaptureRedact,
+		"capture-redact",
+		defaults.ExecCaptureRedact,
+		"with --capture, a regular expression for text in the stderr tail to replace with [REDACTED]",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecSampleInterval,
+		"sample-interval",
+		defaults.ExecSampleInterval,
+		"sample the child's cpu, memory, and io from /proc at this interval into span events (linux only)",
+	)
+
+	cmd.Flags().StringToStringVar(
+		&config.ExecStatusMap,
+		"status-map",
+		defaults.ExecStatusMap,
+		"map exit codes, signal names, or 'timeout' to a span status, e.g. 1=ok,124=error:timed out",
+	)
+
+	cmd.Flags().IntVar(
+		&config.ExecRetries,
+		"retries",
+		defaults.ExecRetries,
+		"retry a failed command up to this many times, each attempt gets a child span",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRetryDelay,
+		"retry-delay",
+		defaults.ExecRetryDelay,
+		"with --retries, how long to wait before the first retry, doubling for each retry after that",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRetryMaxDelay,
+		"retry-max-delay",
+		defaults.ExecRetryMaxDelay,
+		"with --retries, the longest to wait between attempts, set it to --retry-delay for a constant delay",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRetryOn,
+		"retry-on",
+		defaults.ExecRetryOn,
+		"with --retries, a comma-separated list of exit codes and/or 'timeout' to retry on, default is any failure",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecSideChannel,
+		"side-channel",
+		defaults.ExecSideChannel,
+		"'fd' or 'fifo', give the command a pipe in OTEL_CLI_FD or OTEL_CLI_FIFO to send events, attributes, status, and child spans",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRelay,
+		"relay",
+		defaults.ExecRelay,
+		"'grpc' or 'http', run a local OTLP server for the command to export to and forward what it sends",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecRelayListen,
+		"relay-listen",
+		defaults.ExecRelayListen,
+		"with --relay, the host:port to listen on, a unix:///path, or 'unix' for a socket in a temp directory",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecPty,
+		"pty",
+		defaults.ExecPty,
+		"run the command on a pseudo-terminal, with its stdout and stderr combined into otel-cli's stdout (linux only)",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecLogs,
+		"logs",
+		defaults.ExecLogs,
+		"send each line of the command's output as an OTLP log record linked to the span",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecLogsInterval,
+		"logs-interval",
+		defaults.ExecLogsInterval,
+		"with --logs, send log records at this interval instead of only after the command exits",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecLogsErrorMatch,
+		"logs-error-match",
+		defaults.ExecLogsErrorMatch,
+		"with --logs, a regular expression for stderr lines to send as ERROR instead of WARN",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ExecExportEnv,
+		"export-env",
+		defaults.ExecExportEnv,
+		"pass otel-cli's endpoint, protocol, headers, TLS files, service name, and resource attributes to the command as OTEL_* variables",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecExportEnvDeny,
+		"export-env-deny",
+		defaults.ExecExportEnvDeny,
+		"with --export-env, comma-separated patterns for variables and headers to leave out, e.g. authorization,*token*",
+	)
+
+	cmd.Flags().StringVar(
+		&config.LogsEndpoint,
+		"logs-endpoint",
+		defaults.LogsEndpoint,
+		"with --logs, endpoint for logs, for HTTP the default is the --endpoint or --traces-endpoint with /v1/logs",
 	)
 
 	return &cmd
@@ -62,124 +243,240 @@ func doExec(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
 	config := getConfig(ctx)
 	span := config.NewProtobufSpan()
-	processAttrs := processArgAttrs(args) // might be overwritten in process setup
+	// named for the command as written, before placeholders are expanded,
+	// so every run of it groups together
+	span.Name = config.GetSpanName(args)
+
+	// --relay forwards what the command exports through otel-cli's client
+	relay, err := newExecRelay(ctx, config, span)
+	if err != nil {
+		config.SoftLog("unable to start relay: %s", err)
+	}
+
+	// with --retries, span covers all of the attempts and each one gets
+	// its own child span, which are sent ahead of it
+	var spans []*tracev1.Span
+	var child *exec.Cmd
+	if config.ExecRetries > 0 {
+		child, spans = execWithRetries(config, span, args, relay)
+	} else {
+		var result execResult
+		child, result = execAttempt(config, span, args, relay)
+		spans = result.spans
+	}
+	if relay != nil {
+		span.Attributes = append(span.Attributes, relay.finish()...)
+	}
+	spans = append(spans, span)
+
+	// capture the child's exit code before OTLP export so SoftFail can use it (#360)
+	if child.ProcessState != nil {
+		Diag.ExecExitCode = child.ProcessState.ExitCode()
+	} else {
+		Diag.ExecExitCode = 127
+	}
+
+	// set --timeout on just the OTLP egress, starting now instead of process start time
+	ctx, cancelCtxDeadline := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancelCtxDeadline()
 
-	// no deadline if there is no command timeout set
-	cancelCtxDeadline := func() {}
-	// fork the context for the command so its deadline doesn't impact the otlpclient ctx
-	cmdCtx := ctx
-	cmdTimeout := config.ParseExecCommandTimeout()
-	if cmdTimeout > 0 {
-		cmdCtx, cancelCtxDeadline = context.WithDeadline(ctx, time.Now().Add(cmdTimeout))
+	ctx, client := StartClient(ctx, config)
+	ctx, err = otlpclient.SendSpans(ctx, client, config, spans)
+	if err != nil {
+		config.failExport("unable to send span: %s", err)
+	}
+
+	ctx, err = client.Stop(ctx)
+	if err != nil {
+		config.failExport("client.Stop() failed: %s", err)
 	}
 
+	config.PropagateTraceparent(ctx, span, os.Stdout)
+}
+
+// execAttempt runs the command once inside the provided span, setting its
+// start and end times, status, attributes, and events. The span's
+// traceparent is passed to the command, along with the relay's endpoint
+// when there is one.
+func execAttempt(config Config, span *tracev1.Span, args []string, relay *execRelay) (*exec.Cmd, execResult) {
 	// pass the existing env but add the latest TRACEPARENT carrier so e.g.
 	// otel-cli exec 'otel-cli exec sleep 1' will relate the spans automatically
 	childEnv := []string{}
 
 	// set the traceparent to the current span to be available to the child process
 	appendChildEnv := func(key, value string) { childEnv = append(childEnv, key+"="+value) }
-	var tp traceparent.Traceparent
-	if config.GetIsRecording() {
-		tp = otlpclient.TraceparentFromProtobufSpan(span, config.GetIsRecording())
-		injectTraceparent(tp, appendChildEnv)
-		// when not recording, and a traceparent is available, pass it through
-	} else if !config.TraceparentIgnoreEnv {
-		passthrough := config.LoadTraceparent()
-		if passthrough.Initialized {
-			injectTraceparent(passthrough, appendChildEnv)
+	tp := execInjectTraceparent(config, span, appendChildEnv)
+
+	// replace {{traceparent}} and friends in the args, see execTemplate
+	template := newExecTemplate(config, tp)
+	expandedArgs := make([]string, len(args))
+	copy(expandedArgs, args)
+	if !config.ExecTpDisableInject {
+		for i, arg := range args[1:] {
+			expandedArgs[i+1] = template.expand(arg)
 		}
 	}
 
-	var child *exec.Cmd
-	if len(args) > 1 {
-		tpArgs := make([]string, len(args)-1)
-
-		if config.ExecTpDisableInject {
-			copy(tpArgs, args[1:])
-		} else {
-			// loop over the args replacing {{traceparent}} with the current tp
-			for i, arg := range args[1:] {
-				tpArgs[i] = strings.Replace(arg, "{{traceparent}}", tp.Encode(), -1)
-			}
+	// --record-args template keeps the attributes the same on every run
+	var processAttrs []*commonpb.KeyValue
+	switch config.ExecRecordArgs {
+	case "template":
+		processAttrs = processArgAttrs(args)
+	case "expanded":
+		processAttrs = processArgAttrs(expandedArgs)
+	default:
+		config.SoftFail("invalid --record-args %q, must be expanded or template", config.ExecRecordArgs)
+		processAttrs = processArgAttrs(expandedArgs)
+	}
 
-			// overwrite process args attributes with the injected values
-			processAttrs = processArgAttrs(append([]string{args[0]}, tpArgs...))
+	// --remote gets the traceparent through ssh, docker, and kubectl
+	var remoteAttrs []*commonpb.KeyValue
+	if config.ExecRemote {
+		if vars := execRemoteVars(config, childEnv, template); len(vars) > 0 {
+			expandedArgs, remoteAttrs = execRemote(config, expandedArgs, vars)
 		}
-
-		child = exec.CommandContext(cmdCtx, args[0], tpArgs...)
-	} else {
-		child = exec.CommandContext(cmdCtx, args[0])
 	}
 
+	child := exec.Command(expandedArgs[0], expandedArgs[1:]...)
+
 	// attach all stdio to the parent's handles
 	child.Stdin = os.Stdin
 	child.Stdout = os.Stdout
 	child.Stderr = os.Stderr
 
-	// grab everything BUT the TRACEPARENT envvar
+	// --capture tees output through counters, which means the child gets
+	// pipes instead of the terminal, so it's opt-in
+	// with --pty everything the command writes comes through stdout, so
+	// that's where the tail is kept
+	var stdoutCapture, stderrCapture *outputCapture
+	if config.ExecCapture {
+		stdoutCapture = newOutputCapture(0, 0)
+		if config.ExecPty {
+			stdoutCapture = newOutputCapture(config.ExecCaptureTailLines, config.ExecCaptureTailBytes)
+		}
+		stderrCapture = newOutputCapture(config.ExecCaptureTailLines, config.ExecCaptureTailBytes)
+		child.Stdout = io.MultiWriter(os.Stdout, stdoutCapture)
+		child.Stderr = io.MultiWriter(os.Stderr, stderrCapture)
+	}
+
+	// --logs tees output the same way, and with --pty it all arrives as stdout
+	logs := newExecLogs(config, span, processAttrs)
+	child.Stdout = logs.wrap(child.Stdout, "stdout")
+	child.Stderr = logs.wrap(child.Stderr, "stderr")
+
+	sideChannel, err := newExecSideChannel(config, span, child, appendChildEnv)
+	if err != nil {
+		config.SoftLog("unable to open side channel: %s", err)
+	}
+
+	var pty *execPty
+	if config.ExecPty {
+		if pty, err = newExecPty(config, child); err != nil {
+			config.SoftLog("unable to open pty: %s", err)
+		} else {
+			// finish restores it too, this covers any way out before then
+			defer pty.restoreTerminal()
+		}
+	}
+
+	// grab everything BUT the TRACEPARENT envvar, and OTEL_CLI_FD and
+	// OTEL_CLI_FIFO since an outer otel-cli's side channel would win over
+	// this one's, which is set above when there is one
 	for _, env := range os.Environ() {
-		if !strings.HasPrefix(env, "TRACEPARENT=") {
+		if !strings.HasPrefix(env, "TRACEPARENT=") && !strings.HasPrefix(env, "OTEL_CLI_FD=") && !strings.HasPrefix(env, "OTEL_CLI_FIFO=") {
 			childEnv = append(childEnv, env)
 		}
 	}
-	child.Env = childEnv
-
-	// ctrl-c (sigint) is forwarded to the child process
-	signals := make(chan os.Signal, 10)
-	signalsDone := make(chan struct{})
-	signal.Notify(signals, os.Interrupt)
-	go func() {
-		sig := <-signals
-		child.Process.Signal(sig)
-		// this might not seem necessary but without it, otel-cli exits before sending the span
-		close(signalsDone)
-	}()
+	// only --env values get placeholders expanded, inherited values are left
+	// as they are since {{...}} in them is likely meant for something else,
+	// and coming last they win over inherited ones
+	for _, env := range config.ExecEnv {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || name == "" {
+			config.SoftFail("invalid --env %q, must be NAME=VALUE", env)
+			continue
+		}
+		if !config.ExecTpDisableInject {
+			value = template.expand(value)
+		}
+		childEnv = append(childEnv, name+"="+value)
+	}
+	// --relay wins over --export-env, the command exports to the relay
+	child.Env = relay.childEnv(execExportEnv(config, childEnv))
 
+	// runChild handles signals and --command-timeout
 	span.StartTimeUnixNano = uint64(time.Now().UnixNano())
-	if err := child.Run(); err != nil {
-		span.Status = &tracev1.Status{
-			Message: fmt.Sprintf("exec command failed: %s", err),
-			Code:    tracev1.Status_STATUS_CODE_ERROR,
-		}
+	result := runChild(config, child)
+	// the side channel reader writes to span, so it's drained and stopped
+	// before anything else here touches span
+	var sideStatus *tracev1.Status
+	if sideChannel != nil {
+		result.spans, sideStatus = sideChannel.finish()
+	}
+	if pty != nil {
+		pty.finish()
+	}
+	if logs != nil {
+		span.Attributes = append(span.Attributes, logs.finish()...)
+	}
+	runErr := result.err
+	span.Events = append(span.Events, result.events...)
+	exitAttrs, status := execExitAttrs(config, result, child.ProcessState)
+	// a status from the command itself wins over the exit code
+	if sideStatus != nil {
+		status = sideStatus
+	}
+	if status != nil {
+		span.Status = status
 	}
 	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
 
 	// append process attributes
 	span.Attributes = append(span.Attributes, processAttrs...)
+	span.Attributes = append(span.Attributes, remoteAttrs...)
+	if config.ExecCapture {
+		tail, tailStream := stderrCapture, "process.stderr"
+		span.Attributes = append(span.Attributes, stdoutCapture.Attrs("process.stdout")...)
+		if pty != nil {
+			tail, tailStream = stdoutCapture, "process.stdout"
+		} else {
+			span.Attributes = append(span.Attributes, stderrCapture.Attrs("process.stderr")...)
+		}
+		if runErr != nil && config.ExecCaptureTailLines > 0 {
+			if event := execOutputTailEvent(config, tail, tailStream, span.EndTimeUnixNano); event != nil {
+				span.Events = append(span.Events, event)
+			}
+		}
+	}
 	// child.Process is nil if the command failed to start (e.g., command not found)
 	if child.Process != nil {
 		pidAttrs := processPidAttrs(config, int64(child.Process.Pid), int64(os.Getpid()))
 		span.Attributes = append(span.Attributes, pidAttrs...)
 	}
-
-	// capture the child's exit code before OTLP export so SoftFail can use it (#360)
+	span.Attributes = append(span.Attributes, exitAttrs...)
 	if child.ProcessState != nil {
-		Diag.ExecExitCode = child.ProcessState.ExitCode()
-	} else {
-		Diag.ExecExitCode = 127
+		span.Attributes = append(span.Attributes, processRusageAttrs(child.ProcessState)...)
 	}
 
-	cancelCtxDeadline()
-	close(signals)
-	<-signalsDone
-
-	// set --timeout on just the OTLP egress, starting now instead of process start time
-	ctx, cancelCtxDeadline = context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
-	defer cancelCtxDeadline()
-
-	ctx, client := StartClient(ctx, config)
-	ctx, err := otlpclient.SendSpan(ctx, client, config, span)
-	if err != nil {
-		config.SoftFail("unable to send span: %s", err)
-	}
+	return child, result
+}
 
-	_, err = client.Stop(ctx)
-	if err != nil {
-		config.SoftFail("client.Stop() failed: %s", err)
+// execInjectTraceparent passes the span's traceparent to a child process with
+// setEnv, or the traceparent otel-cli was given when it isn't recording.
+// Returns the span's traceparent, which is empty when not recording.
+func execInjectTraceparent(config Config, span *tracev1.Span, setEnv func(key, value string)) traceparent.Traceparent {
+	var tp traceparent.Traceparent
+	if config.GetIsRecording() {
+		tp = otlpclient.TraceparentFromProtobufSpan(span, config.GetIsRecording())
+		injectTraceparent(tp, setEnv)
+		// when not recording, and a traceparent is available, pass it through
+	} else if !config.TraceparentIgnoreEnv {
+		passthrough := config.LoadTraceparent()
+		if passthrough.Initialized {
+			injectTraceparent(passthrough, setEnv)
+		}
 	}
-
-	config.PropagateTraceparent(span, os.Stdout)
+	return tp
 }
 
 // processArgAttrs turns the provided args list into OTel attributes
@@ -241,3 +538,25 @@ func processPidAttrs(config Config, ppid, pid int64) []*commonpb.KeyValue {
 		},
 	}
 }
+
+// int64Attr returns an int-valued attribute ready to append to a protobuf
+// span's span.Attributes.
+func int64Attr(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key: key,
+		Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_IntValue{IntValue: value},
+		},
+	}
+}
+
+// boolAttr returns a bool-valued attribute ready to append to a protobuf
+// span's span.Attributes.
+func boolAttr(key string, value bool) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key: key,
+		Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_BoolValue{BoolValue: value},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package otelcli
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// defaultExecCaptureRedact matches the usual suspects for secrets showing up
+// in error output, e.g. "password=hunter2" or "Authorization: Bearer abc123".
+const defaultExecCaptureRedact = `(?i)(password|passwd|secret|token|api[_-]?key|bearer)["']?\s*[:= ]\s*\S+`
+
+// outputCapture is an io.Writer that counts the bytes and lines written to it
+// and keeps the last few lines, for teeing a child's stdout or stderr.
+// exec.Cmd copies each stream from its own goroutine and Wait() doesn't
+// return until copying is done, so no locking is needed.
+type outputCapture struct {
+	bytes    int64
+	newlines int64
+	maxLines int      // how many lines to keep, 0 to only count
+	maxBytes int      // cap on the unterminated line so it can't grow forever
+	tail     []string // ring of the last maxLines complete lines
+	next     int      // next slot in tail to overwrite once it's full
+	partial  []byte   // the current line that hasn't seen a newline yet
+	endsNL   bool     // whether the last byte written was a newline
+}
+
+// newOutputCapture returns an outputCapture that keeps up to maxLines lines,
+// each at most maxBytes long.
+func newOutputCapture(maxLines, maxBytes int) *outputCapture {
+	return &outputCapture{
+		maxLines: maxLines,
+		maxBytes: maxBytes,
+		tail:     []string{},
+	}
+}
+
+// Write implements io.Writer. It never fails.
+func (oc *outputCapture) Write(p []byte) (int, error) {
+	oc.bytes += int64(len(p))
+	oc.newlines += int64(bytes.Count(p, []byte{'\n'}))
+	if len(p) > 0 {
+		oc.endsNL = p[len(p)-1] == '\n'
+	}
+
+	if oc.maxLines <= 0 {
+		return len(p), nil
+	}
+
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			oc.appendPartial(data)
+			break
+		}
+		oc.appendPartial(data[:i])
+		oc.pushLine(string(oc.partial))
+		oc.partial = oc.partial[:0]
+		data = data[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// appendPartial adds to the current line, dropping anything past maxBytes.
+func (oc *outputCapture) appendPartial(data []byte) {
+	if room := oc.maxBytes - len(oc.partial); oc.maxBytes > 0 && len(data) > room {
+		data = data[:max(room, 0)]
+	}
+	oc.partial = append(oc.partial, data...)
+}
+
+// pushLine adds a complete line to the tail, evicting the oldest when full.
+func (oc *outputCapture) pushLine(line string) {
+	if len(oc.tail) < oc.maxLines {
+		oc.tail = append(oc.tail, line)
+		return
+	}
+	oc.tail[oc.next] = line
+	oc.next = (oc.next + 1) % oc.maxLines
+}
+
+// Lines returns the number of lines written, counting a final line that
+// doesn't end in a newline.
+func (oc *outputCapture) Lines() int64 {
+	if oc.bytes > 0 && !oc.endsNL {
+		return oc.newlines + 1
+	}
+	return oc.newlines
+}
+
+// Tail returns the last lines written, oldest first, with matches of redact
+// replaced, and trimmed from the front to fit in maxBytes. The bool is true
+// when any lines or bytes were left out.
+func (oc *outputCapture) Tail(maxBytes int, redact *regexp.Regexp) (string, bool) {
+	lines := make([]string, 0, len(oc.tail)+1)
+	lines = append(lines, oc.tail[oc.next:]...)
+	lines = append(lines, oc.tail[:oc.next]...)
+	if len(oc.partial) > 0 {
+		lines = append(lines, string(oc.partial))
+	}
+
+	truncated := int64(len(lines)) < oc.Lines()
+	if redact != nil {
+		for i, line := range lines {
+			lines[i] = redact.ReplaceAllString(line, "[REDACTED]")
+		}
+	}
+
+	out := strings.Join(lines, "\n")
+	if maxBytes > 0 && len(out) > maxBytes {
+		// keep the end since that's usually where the error is
+		out = strings.ToValidUTF8(out[len(out)-maxBytes:], "")
+		truncated = true
+	}
+
+	return out, truncated
+}
+
+// Attrs returns the byte and line counts as attributes named prefix.bytes
+// and prefix.lines, e.g. process.stdout.bytes.
+func (oc *outputCapture) Attrs(prefix string) []*commonpb.KeyValue {
+	return []*commonpb.KeyValue{
+		int64Attr(prefix+".bytes", oc.bytes),
+		int64Attr(prefix+".lines", oc.Lines()),
+	}
+}
+
+// execOutputTailEvent returns a span event named for the stream, e.g.
+// process.stderr, holding the end of the child's output on it, or nil if the
+// child didn't write anything there.
+func execOutputTailEvent(config Config, capture *outputCapture, stream string, ts uint64) *tracev1.Span_Event {
+	if capture.bytes == 0 {
+		return nil
+	}
+
+	var redact *regexp.Regexp
+	if config.ExecCaptureRedact != "" {
+		var err error
+		redact, err = regexp.Compile(config.ExecCaptureRedact)
+		if err != nil {
+			config.SoftFail("invalid --capture-redact regular expression: %s", err)
+			return nil
+		}
+	}
+
+	tail, truncated := capture.Tail(config.ExecCaptureTailBytes, redact)
+
+	event := otlpclient.NewProtobufSpanEvent()
+	event.Name = stream
+	event.TimeUnixNano = ts
+	event.Attributes = []*commonpb.KeyValue{
+		{
+			Key: stream + ".tail",
+			Value: &commonpb.AnyValue{
+				Value: &commonpb.AnyValue_StringValue{StringValue: tail},
+			},
+		},
+		{
+			Key: stream + ".truncated",
+			Value: &commonpb.AnyValue{
+				Value: &commonpb.AnyValue_BoolValue{BoolValue: truncated},
+			},
+		},
+	}
+
+	return event
+}
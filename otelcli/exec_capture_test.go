@@ -0,0 +1,59 @@
+package otelcli
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestOutputCaptureCounts(t *testing.T) {
+	for _, tc := range []struct {
+		writes []string
+		bytes  int64
+		lines  int64
+	}{
+		{writes: []string{}, bytes: 0, lines: 0},
+		{writes: []string{"hello\n"}, bytes: 6, lines: 1},
+		{writes: []string{"hello"}, bytes: 5, lines: 1},
+		{writes: []string{"a\nb", "\nc"}, bytes: 5, lines: 3},
+		{writes: []string{"\n\n\n"}, bytes: 3, lines: 3},
+	} {
+		// counts must not depend on whether lines are being kept
+		for _, maxLines := range []int{0, 2} {
+			oc := newOutputCapture(maxLines, 100)
+			for _, w := range tc.writes {
+				oc.Write([]byte(w))
+			}
+			if oc.bytes != tc.bytes || oc.Lines() != tc.lines {
+				t.Errorf("writes %q with %d lines kept: got %d bytes %d lines, expected %d bytes %d lines",
+					tc.writes, maxLines, oc.bytes, oc.Lines(), tc.bytes, tc.lines)
+			}
+		}
+	}
+}
+
+func TestOutputCaptureTail(t *testing.T) {
+	oc := newOutputCapture(3, 100)
+	oc.Write([]byte("one\ntwo\nthr"))
+	oc.Write([]byte("ee\nfour\npassword=hunter2 failed\n"))
+
+	tail, truncated := oc.Tail(0, regexp.MustCompile(defaultExecCaptureRedact))
+	if tail != "three\nfour\n[REDACTED] failed" {
+		t.Errorf("unexpected tail %q", tail)
+	}
+	if !truncated {
+		t.Error("expected truncated to be true when lines were dropped")
+	}
+
+	// a byte limit keeps the end of the output
+	tail, truncated = oc.Tail(6, nil)
+	if tail != "failed" || !truncated {
+		t.Errorf("expected byte-limited tail \"failed\" and truncated but got %q, %t", tail, truncated)
+	}
+
+	oc = newOutputCapture(3, 100)
+	oc.Write([]byte("error: no such file"))
+	tail, truncated = oc.Tail(100, nil)
+	if tail != "error: no such file" || truncated {
+		t.Errorf("expected the unterminated line untruncated but got %q, %t", tail, truncated)
+	}
+}
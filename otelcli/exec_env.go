@@ -0,0 +1,234 @@
+package otelcli
+
+import (
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultExecExportEnvDeny keeps the usual suspects for credentials out of
+// the environment --export-env gives the command, e.g. an authorization
+// header in OTEL_EXPORTER_OTLP_HEADERS.
+const defaultExecExportEnvDeny = "authorization,*api?key*,*token*,*secret*,*password*"
+
+// execExportEnv replaces the OTel SDK variables in env with otel-cli's
+// effective config when --export-env is on, so an instrumented command
+// exports to the same place, the same way, as the same service.
+func execExportEnv(config Config, env []string) []string {
+	if !config.ExecExportEnv {
+		return env
+	}
+
+	// every variable otel-cli knows is replaced, or dropped when it's
+	// empty or denied, so nothing inherited can contradict or leak past it
+	export := execSdkEnv(config, env)
+	out := make([]string, 0, len(env)+len(export))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, replaced := export[name]; !replaced {
+			out = append(out, kv)
+		}
+	}
+
+	names := make([]string, 0, len(export))
+	for name, value := range export {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out = append(out, name+"="+export[name])
+	}
+
+	return out
+}
+
+// execSdkEnv returns otel-cli's config as the standard OTEL_* variables
+// named in the Config struct's env tags, plus OTEL_RESOURCE_ATTRIBUTES.
+// Values are empty for unset config, for anything matching --export-env-deny,
+// and for the tags' other names, e.g. OTEL_EXPORTER_OTLP_TRACES_PROTOCOL.
+func execSdkEnv(config Config, env []string) map[string]string {
+	deny := execExportEnvDenied(config.ExecExportEnvDeny)
+	out := map[string]string{}
+
+	structType := reflect.TypeOf(config)
+	cValue := reflect.ValueOf(config)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		names := sdkEnvNames(field.Tag.Get("env"))
+		if len(names) == 0 {
+			continue
+		}
+		for _, name := range names {
+			out[name] = ""
+		}
+		name := names[0]
+		if deny(name) {
+			continue
+		}
+
+		var value string
+		switch field.Name {
+		case "Endpoint", "TracesEndpoint", "LogsEndpoint":
+			value = sdkEndpoint(cValue.Field(i).String(), config)
+		case "Protocol":
+			// otel-cli guesses from the endpoint, SDKs don't all guess the same
+			value = config.Protocol
+			if value == "" && config.GetIsRecording() {
+				value = "grpc"
+				if scheme := config.GetEndpoint().Scheme; scheme == "http" || scheme == "https" {
+					value = "http/protobuf"
+				}
+			}
+		case "Timeout":
+			// SDKs want milliseconds
+			if timeout, err := parseDuration(config.Timeout); err == nil && timeout > 0 {
+				value = strconv.FormatInt(timeout.Milliseconds(), 10)
+			}
+		case "Blocking":
+			// deprecated, and not something SDKs know about
+			continue
+		default:
+			switch v := cValue.Field(i).Interface().(type) {
+			case string:
+				value = v
+			case bool:
+				if v {
+					value = "true"
+				}
+			case int:
+				value = strconv.Itoa(v)
+			case map[string]string:
+				// SDKs percent-decode both sides, so a , or = in a value
+				// can't split it into more headers
+				allowed := map[string]string{}
+				for k, kv := range v {
+					if !deny(k) {
+						allowed[sdkEnvEscape(k)] = sdkEnvEscape(kv)
+					}
+				}
+				value = flattenStringMap(allowed, "")
+			}
+		}
+
+		out[name] = value
+	}
+
+	out["OTEL_RESOURCE_ATTRIBUTES"] = ""
+	if !deny("OTEL_RESOURCE_ATTRIBUTES") {
+		attrs := map[string]string{}
+		for k, v := range sdkResourceAttributes(config, env) {
+			if !deny(k) {
+				attrs[sdkEnvEscape(k)] = sdkEnvEscape(v)
+			}
+		}
+		out["OTEL_RESOURCE_ATTRIBUTES"] = flattenStringMap(attrs, "")
+	}
+
+	return out
+}
+
+// sdkResourceAttributes returns the resource attributes otel-cli sends with,
+// the ones in OTEL_RESOURCE_ATTRIBUTES in env plus service.name.
+func sdkResourceAttributes(config Config, env []string) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		if name != "OTEL_RESOURCE_ATTRIBUTES" {
+			continue
+		}
+		// a later one, e.g. from --env, replaces the inherited one
+		attrs = map[string]string{}
+		for _, attr := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(attr, "=")
+			if !ok {
+				continue
+			}
+			k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+			if uk, err := url.PathUnescape(k); err == nil {
+				k = uk
+			}
+			if uv, err := url.PathUnescape(v); err == nil {
+				v = uv
+			}
+			if k != "" {
+				attrs[k] = v
+			}
+		}
+	}
+
+	if config.ServiceName != "" {
+		attrs["service.name"] = config.ServiceName
+	}
+
+	return attrs
+}
+
+// sdkEnvNames returns the standard OTel variables in a field's env tag,
+// e.g. OTEL_SERVICE_NAME from OTEL_CLI_SERVICE_NAME,OTEL_SERVICE_NAME, or
+// none when otel-cli is the only thing that reads it.
+func sdkEnvNames(tag string) []string {
+	names := []string{}
+	for _, name := range strings.Split(tag, ",") {
+		if strings.HasPrefix(name, "OTEL_") && !strings.HasPrefix(name, "OTEL_CLI_") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sdkEnvEscape percent-encodes a key or value for the comma-separated
+// key=value lists in OTEL_EXPORTER_OTLP_HEADERS and OTEL_RESOURCE_ATTRIBUTES.
+func sdkEnvEscape(s string) string {
+	// QueryEscape's + for space isn't percent-encoding, SDKs would keep it
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// sdkEndpoint turns otel-cli's endpoint shorthands, bare host:port and
+// grpc://, into the http(s):// URLs SDKs expect, using TLS only when
+// otel-cli does, e.g. not for a loopback endpoint.
+func sdkEndpoint(endpoint string, config Config) string {
+	if endpoint == "" {
+		return ""
+	}
+
+	scheme, rest, found := strings.Cut(endpoint, "://")
+	if found && scheme != "grpc" {
+		return endpoint // http, https, unix
+	} else if !found {
+		rest = endpoint
+	}
+	if !strings.Contains(rest, ":") {
+		rest += ":4317"
+	}
+
+	if config.GetInsecure() {
+		return "http://" + rest
+	}
+	return "https://" + rest
+}
+
+// execExportEnvDenied returns a func that's true for the variable and
+// header names matching the comma-separated patterns in deny, ignoring case.
+func execExportEnvDenied(deny string) func(string) bool {
+	patterns := []string{}
+	for _, p := range strings.Split(deny, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, strings.ToLower(p))
+		}
+	}
+
+	return func(name string) bool {
+		name = strings.ToLower(name)
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecExportEnv(t *testing.T) {
+	env := []string{
+		"HOME=/home/test",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT=https://elsewhere.example.com/v1/traces",
+		"OTEL_EXPORTER_OTLP_HEADERS=authorization=Bearer inherited",
+		"OTEL_RESOURCE_ATTRIBUTES=team=obs,region=us%20east",
+	}
+
+	config := DefaultConfig().WithEndpoint("192.0.2.10:4317").WithServiceName("deploy")
+	if diff := cmp.Diff(env, execExportEnv(config, env)); diff != "" {
+		t.Errorf("env should be unchanged without --export-env (-want +got):\n%s", diff)
+	}
+
+	config.ExecExportEnv = true
+	config.Headers = map[string]string{"authorization": "Bearer abc123", "x-team": "obs", "x-note": "a=b,c%d"}
+	config.TlsCACert = "/etc/ssl/ca.pem"
+	want := []string{
+		"HOME=/home/test",
+		"OTEL_EXPORTER_OTLP_CERTIFICATE=/etc/ssl/ca.pem",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=https://192.0.2.10:4317",
+		"OTEL_EXPORTER_OTLP_HEADERS=x-note=a%3Db%2Cc%25d,x-team=obs",
+		"OTEL_EXPORTER_OTLP_PROTOCOL=grpc",
+		"OTEL_EXPORTER_OTLP_TIMEOUT=1000",
+		"OTEL_RESOURCE_ATTRIBUTES=region=us%20east,service.name=deploy,team=obs",
+		"OTEL_SERVICE_NAME=deploy",
+	}
+	if diff := cmp.Diff(want, execExportEnv(config, env)); diff != "" {
+		t.Errorf("exported env didn't match (-want +got):\n%s", diff)
+	}
+
+	// deny whole variables too, and an empty deny list lets secrets through
+	config.ExecExportEnvDeny = "otel_exporter_otlp_certificate"
+	want = []string{
+		"HOME=/home/test",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=https://192.0.2.10:4317",
+		"OTEL_EXPORTER_OTLP_HEADERS=authorization=Bearer%20abc123,x-note=a%3Db%2Cc%25d,x-team=obs",
+		"OTEL_EXPORTER_OTLP_PROTOCOL=grpc",
+		"OTEL_EXPORTER_OTLP_TIMEOUT=1000",
+		"OTEL_RESOURCE_ATTRIBUTES=region=us%20east,service.name=deploy,team=obs",
+		"OTEL_SERVICE_NAME=deploy",
+	}
+	if diff := cmp.Diff(want, execExportEnv(config, env)); diff != "" {
+		t.Errorf("exported env with a custom deny list didn't match (-want +got):\n%s", diff)
+	}
+
+	// resource attributes from --env win over inherited ones, and can be denied
+	config.ExecExportEnvDeny = "team"
+	got := execExportEnv(config, append(env, "OTEL_RESOURCE_ATTRIBUTES=team=dev,host=a%2Cb"))
+	if diff := cmp.Diff("OTEL_RESOURCE_ATTRIBUTES=host=a%2Cb,service.name=deploy", got[len(got)-2]); diff != "" {
+		t.Errorf("exported resource attributes didn't match (-want +got):\n%s", diff)
+	}
+}
+
+func TestSdkEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		insecure bool
+		want     string
+	}{
+		{"localhost", true, "http://localhost:4317"},
+		// otel-cli doesn't use TLS for loopback, so neither should the command
+		{"localhost:4317", false, "http://localhost:4317"},
+		{"192.0.2.10:4317", false, "https://192.0.2.10:4317"},
+		{"grpc://192.0.2.10:4317", true, "http://192.0.2.10:4317"},
+		{"http://localhost:4318", false, "http://localhost:4318"},
+		{"unix:///tmp/otlp.sock", false, "unix:///tmp/otlp.sock"},
+		{"", false, ""},
+	} {
+		config := DefaultConfig().WithEndpoint(tc.endpoint).WithInsecure(tc.insecure)
+		if got := sdkEndpoint(tc.endpoint, config); got != tc.want {
+			t.Errorf("sdkEndpoint(%q, %t) = %q, want %q", tc.endpoint, tc.insecure, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// execCanFollowStops is true where execFollowStops works.
+const execCanFollowStops = true
+
+// execCldStopped is CLD_STOPPED from <signal.h>, the siginfo code for a
+// child that was stopped, e.g. by ^Z.
+const execCldStopped = 5
+
+// execFollowStops keeps job control working while the child has otel-cli's
+// terminal. When the child is stopped, e.g. by ^Z, otel-cli takes the terminal
+// back and stops itself so the shell sees the job stop. When the shell
+// continues it, the child is continued too, with the terminal if the job is
+// in the foreground. Returns once the child has exited.
+func execFollowStops(child *exec.Cmd, tty int) {
+	pid := child.Process.Pid
+	for {
+		// WNOWAIT leaves the exit for child.Wait, and after ECHILD it's gone
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WSTOPPED|unix.WEXITED|unix.WNOWAIT, nil)
+		if err == unix.EINTR {
+			continue
+		} else if err != nil || info.Code != execCldStopped {
+			return
+		}
+
+		execReclaimTerminal(tty, child)
+
+		cont := make(chan os.Signal, 1)
+		signal.Notify(cont, syscall.SIGCONT)
+		syscall.Kill(0, syscall.SIGTSTP)
+		<-cont
+		signal.Stop(cont)
+
+		// after fg the shell has given the terminal back to otel-cli's group
+		if execForegroundTerminal() == tty {
+			unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, pid)
+		}
+		syscall.Kill(-pid, syscall.SIGCONT)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package otelcli
+
+import "os/exec"
+
+// execCanFollowStops is false, there's no way to wait for a child to stop
+// without also reaping it when it exits.
+const execCanFollowStops = false
+
+// execFollowStops is never called since the child is never handed the terminal.
+func execFollowStops(child *exec.Cmd, tty int) {}
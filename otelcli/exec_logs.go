@@ -0,0 +1,234 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// defaultExecLogsErrorMatch picks out the stderr lines that get ERROR
+// severity instead of WARN.
+const defaultExecLogsErrorMatch = `(?i)\b(error|fatal|panic|failed)\b`
+
+// execLogsMaxBatch is how many records to hold before sending them without
+// waiting for --logs-interval or the command to exit.
+const execLogsMaxBatch = 512
+
+// execLogs turns each line the command writes into an OTLP log record
+// linked to its span, and sends them over the logs signal in batches.
+type execLogs struct {
+	config     Config
+	span       *tracev1.Span
+	resource   []*commonpb.KeyValue
+	errorMatch *regexp.Regexp
+	client     otlpclient.OTLPClient
+	full       chan struct{} // poked when a batch is ready to go
+	done       chan struct{} // closed by finish to stop the flusher
+	flushed    chan struct{} // closed by the flusher once it's stopped
+	writers    []*execLogsWriter
+
+	mu      sync.Mutex // guards everything below
+	records []*logspb.LogRecord
+	sent    int64
+	failed  int64
+}
+
+// newExecLogs returns an execLogs for the span with the process attributes
+// as the resource, or nil when --logs is off or otel-cli isn't recording.
+func newExecLogs(config Config, span *tracev1.Span, processAttrs []*commonpb.KeyValue) *execLogs {
+	if !config.ExecLogs || !config.GetIsRecording() {
+		return nil
+	}
+
+	var errorMatch *regexp.Regexp
+	if config.ExecLogsErrorMatch != "" {
+		var err error
+		if errorMatch, err = regexp.Compile(config.ExecLogsErrorMatch); err != nil {
+			config.SoftFail("invalid --logs-error-match regular expression: %s", err)
+		}
+	}
+
+	// this client outlives the command, so it gets its own context and
+	// each request gets --timeout
+	_, client := StartClient(context.Background(), config)
+
+	el := execLogs{
+		config:     config,
+		span:       span,
+		resource:   processAttrs,
+		errorMatch: errorMatch,
+		client:     client,
+		full:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		flushed:    make(chan struct{}),
+	}
+	go el.flusher(config.ParseExecLogsInterval())
+
+	return &el
+}
+
+// wrap returns a writer that copies to out and turns what's written into
+// log records for the stream, "stdout" or "stderr". Nil-safe, returns out
+// as-is when there's no execLogs.
+func (el *execLogs) wrap(out io.Writer, stream string) io.Writer {
+	if el == nil {
+		return out
+	}
+
+	w := execLogsWriter{logs: el, stream: stream}
+	el.writers = append(el.writers, &w)
+	return io.MultiWriter(out, &w)
+}
+
+// flusher sends the records at every interval, if there is one, and when
+// a batch fills up, until finish is called.
+func (el *execLogs) flusher(interval time.Duration) {
+	defer close(el.flushed)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			el.flush()
+		case <-el.full:
+			el.flush()
+		case <-el.done:
+			return
+		}
+	}
+}
+
+// add appends a record for a line of output, poking the flusher when the
+// batch is full.
+func (el *execLogs) add(stream, line string) {
+	now := uint64(time.Now().UnixNano())
+	severity, text := logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"
+	if stream == "stderr" {
+		severity, text = logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"
+		if el.errorMatch != nil && el.errorMatch.MatchString(line) {
+			severity, text = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR"
+		}
+	}
+
+	record := logspb.LogRecord{
+		TimeUnixNano:         now,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       severity,
+		SeverityText:         text,
+		Body: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_StringValue{StringValue: line},
+		},
+		Attributes: []*commonpb.KeyValue{stringAttr("log.iostream", stream)},
+		TraceId:    el.span.TraceId,
+		SpanId:     el.span.SpanId,
+		Flags:      1, // W3C trace flags, otel-cli's spans are always sampled
+	}
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.records = append(el.records, &record)
+	if len(el.records) >= execLogsMaxBatch {
+		select {
+		case el.full <- struct{}{}:
+		default: // already poked
+		}
+	}
+}
+
+// flush sends the records collected so far in one request.
+func (el *execLogs) flush() {
+	el.mu.Lock()
+	records := el.records
+	el.records = nil
+	el.mu.Unlock()
+
+	if len(records) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), el.config.GetTimeout())
+	defer cancel()
+	_, err := otlpclient.SendLogs(ctx, el.client, el.config, el.resource, records)
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if err != nil {
+		el.config.SoftLog("unable to send %d log records: %s", len(records), err)
+		el.failed += int64(len(records))
+	} else {
+		el.sent += int64(len(records))
+	}
+}
+
+// finish turns any unterminated lines into records and sends the last of
+// them, then returns the exec.logs.* attributes for the span. Must be
+// called after the command has exited and its output has been copied.
+func (el *execLogs) finish() []*commonpb.KeyValue {
+	for _, w := range el.writers {
+		w.flushPartial()
+	}
+
+	close(el.done)
+	<-el.flushed
+	el.flush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), el.config.GetTimeout())
+	defer cancel()
+	if _, err := el.client.Stop(ctx); err != nil {
+		el.config.SoftLog("unable to stop the logs client: %s", err)
+	}
+
+	return []*commonpb.KeyValue{
+		int64Attr("exec.logs.records", el.sent),
+		int64Attr("exec.logs.failed_records", el.failed),
+	}
+}
+
+// execLogsWriter splits one of the command's output streams into lines.
+// exec.Cmd copies each stream from its own goroutine, so it needs no
+// locking of its own.
+type execLogsWriter struct {
+	logs    *execLogs
+	stream  string
+	partial []byte
+}
+
+// Write implements io.Writer. It never fails.
+func (w *execLogsWriter) Write(p []byte) (int, error) {
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.partial = appendLimited(w.partial, data)
+			break
+		}
+		w.partial = appendLimited(w.partial, data[:i])
+		w.logs.add(w.stream, string(bytes.TrimSuffix(w.partial, []byte{'\r'})))
+		w.partial = w.partial[:0]
+		data = data[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flushPartial turns a last line with no newline into a record.
+func (w *execLogsWriter) flushPartial() {
+	if len(w.partial) > 0 {
+		w.logs.add(w.stream, string(bytes.TrimSuffix(w.partial, []byte{'\r'})))
+		w.partial = w.partial[:0]
+	}
+}
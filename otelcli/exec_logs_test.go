@@ -0,0 +1,48 @@
+package otelcli
+
+import (
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tobert/otel-cli/otlpclient"
+)
+
+func TestExecLogsWriter(t *testing.T) {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = otlpclient.GenerateTraceId()
+	span.SpanId = otlpclient.GenerateSpanId()
+	el := execLogs{
+		span:       span,
+		errorMatch: regexp.MustCompile(defaultExecLogsErrorMatch),
+		full:       make(chan struct{}, 1),
+	}
+
+	stdout := el.wrap(io.Discard, "stdout")
+	stderr := el.wrap(io.Discard, "stderr")
+	stdout.Write([]byte("hello\r\nwor"))
+	stderr.Write([]byte("careful\nbuild FAILED\n"))
+	stdout.Write([]byte("ld\nno newline"))
+	for _, w := range el.writers {
+		w.flushPartial()
+	}
+
+	got := []string{}
+	for _, r := range el.records {
+		if string(r.TraceId) != string(span.TraceId) || string(r.SpanId) != string(span.SpanId) {
+			t.Errorf("record %q isn't linked to the span", r.Body.GetStringValue())
+		}
+		got = append(got, r.SeverityText+" "+r.Attributes[0].Value.GetStringValue()+" "+r.Body.GetStringValue())
+	}
+	want := []string{
+		"INFO stdout hello",
+		"WARN stderr careful",
+		"ERROR stderr build FAILED",
+		"INFO stdout world",
+		"INFO stdout no newline",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("log records didn't match (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,161 @@
+package otelcli
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// execPtyPollMs is how often, in milliseconds, the stdin copy checks whether
+// it should stop while nothing is being typed.
+const execPtyPollMs = 100
+
+// execPtyDrain is how long to keep copying output after the command exits,
+// in case it left something running that still has the terminal open.
+const execPtyDrain = 100 * time.Millisecond
+
+// execPty runs the command on a pseudo-terminal so it behaves like it does
+// in an interactive shell, colors, progress bars, and all. Its output is
+// copied to wherever it would have gone without --pty, --capture included,
+// and stdin is copied to it with otel-cli's terminal in raw mode so keys
+// like ^C and ^Z reach the command untouched.
+type execPty struct {
+	config  Config
+	master  *os.File // otel-cli's end
+	tty     *os.File // the command's end
+	restore func()   // puts otel-cli's terminal back how it was, see restoreTerminal
+	once    sync.Once
+	resized chan os.Signal
+	copied  chan struct{}
+	stop    chan struct{} // closed by finish to end the stdin copy
+	input   chan struct{} // closed when the stdin copy is done
+}
+
+// newExecPty opens a pty and attaches it to the child's stdin, stdout, and
+// stderr as its controlling terminal. Must be called after the child's
+// output writers are set up and before it starts.
+func newExecPty(config Config, child *exec.Cmd) (*execPty, error) {
+	master, tty, err := openExecPty()
+	if err != nil {
+		return nil, err
+	}
+
+	p := execPty{
+		config:  config,
+		master:  master,
+		tty:     tty,
+		restore: func() {},
+		resized: make(chan os.Signal, 1),
+		copied:  make(chan struct{}),
+		stop:    make(chan struct{}),
+		input:   make(chan struct{}),
+	}
+
+	in, out := child.Stdin, child.Stdout
+	execPtyAttach(child, tty)
+
+	// the command gets otel-cli's window size and follows it when it changes
+	p.resize()
+	if len(execResizeSignals) > 0 { // Notify with no signals means all of them
+		signal.Notify(p.resized, execResizeSignals...)
+	}
+	go func() {
+		for range p.resized {
+			p.resize()
+		}
+	}()
+
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err != nil {
+			config.SoftLog("unable to put the terminal in raw mode: %s", err)
+		} else {
+			p.restore = func() { term.Restore(fd, state) }
+		}
+	}
+
+	go func() {
+		defer close(p.copied)
+		// reads fail with EIO once the command and everything it started
+		// have closed the terminal, which is how it ends
+		io.Copy(out, p.master)
+	}()
+
+	go func() {
+		defer close(p.input)
+		// at the end of input, the terminal's EOF character (^D) tells a
+		// command reading from it that there's no more
+		if p.copyInput(in) {
+			p.master.Write([]byte{4})
+		}
+	}()
+
+	return &p, nil
+}
+
+// copyInput copies in to the pty until in is at EOF, returning true, or
+// until finish is called. otel-cli's stdin is polled rather than read, so
+// the copy doesn't stay blocked in a read after the command is gone.
+func (p *execPty) copyInput(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		_, err := io.Copy(p.master, in)
+		return err == nil
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-p.stop:
+			return false
+		default:
+		}
+
+		ready, err := execPollInput(f, execPtyPollMs)
+		if err != nil {
+			return false
+		} else if !ready {
+			continue
+		}
+
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, werr := p.master.Write(buf[:n]); werr != nil {
+				return false
+			}
+		}
+		if err == io.EOF {
+			return true
+		} else if err != nil {
+			return false
+		}
+	}
+}
+
+// restoreTerminal puts otel-cli's terminal back how it was. Safe to call
+// more than once, so it can be deferred as well as called by finish.
+func (p *execPty) restoreTerminal() {
+	p.once.Do(p.restore)
+}
+
+// finish waits for the command's output to be copied once it has exited,
+// stops copying stdin, then puts otel-cli's terminal back how it was.
+func (p *execPty) finish() {
+	signal.Stop(p.resized)
+	close(p.resized)
+	close(p.stop)
+
+	p.tty.Close()
+	if err := p.master.SetReadDeadline(time.Now().Add(execPtyDrain)); err != nil {
+		p.config.SoftLog("pty: %s", err)
+	}
+	<-p.copied
+	<-p.input
+	p.master.Close()
+
+	p.restoreTerminal()
+}
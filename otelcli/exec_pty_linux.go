@@ -0,0 +1,97 @@
+//go:build linux
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// execResizeSignals tell otel-cli its terminal changed size.
+var execResizeSignals = []os.Signal{syscall.SIGWINCH}
+
+// openExecPty opens a new pty, returning the master and the terminal.
+func openExecPty() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// going through SyscallConn leaves the master non-blocking, which
+	// finish needs to set a read deadline
+	var n uint32
+	var ioctlErr error
+	rc, err := master.SyscallConn()
+	if err == nil {
+		err = rc.Control(func(fd uintptr) {
+			if ioctlErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ioctlErr == nil {
+				n, ioctlErr = unix.IoctlGetUint32(int(fd), unix.TIOCGPTN)
+			}
+		})
+	}
+	if err == nil {
+		err = ioctlErr
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	tty, err := os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(n), 10), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	return master, tty, nil
+}
+
+// execPtyAttach gives the child the terminal for stdin, stdout, and stderr,
+// in a new session with the terminal as its controlling terminal, which
+// also puts it in its own process group.
+func execPtyAttach(child *exec.Cmd, tty *os.File) {
+	child.Stdin, child.Stdout, child.Stderr = tty, tty, tty
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
+
+// execPollInput waits up to timeoutMs for f to have something to read,
+// returning false if it doesn't. End of input and errors count as readable
+// so the read that follows reports them.
+func execPollInput(f *os.File, timeoutMs int) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, timeoutMs)
+	if err == unix.EINTR {
+		return false, nil
+	}
+	return n > 0, err
+}
+
+// resize copies the window size of whichever of otel-cli's stdin, stdout,
+// or stderr is a terminal to the pty, which sends the command SIGWINCH.
+func (p *execPty) resize() {
+	for _, f := range []*os.File{os.Stdin, os.Stdout, os.Stderr} {
+		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+		if err != nil {
+			continue
+		}
+
+		var ioctlErr error
+		rc, err := p.master.SyscallConn()
+		if err == nil {
+			err = rc.Control(func(fd uintptr) {
+				ioctlErr = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, ws)
+			})
+		}
+		if err == nil {
+			err = ioctlErr
+		}
+		if err != nil {
+			p.config.SoftLog("unable to resize pty: %s", err)
+		}
+		return
+	}
+}
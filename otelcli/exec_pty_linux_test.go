@@ -0,0 +1,46 @@
+package otelcli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestExecPtyFinishStopsInput(t *testing.T) {
+	// stdin is a pipe that's never written or closed, like an idle terminal
+	stdin, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %s", err)
+	}
+	defer stdin.Close()
+	defer stdinW.Close()
+
+	var out bytes.Buffer
+	child := exec.Command("/bin/sh", "-c", "echo hi")
+	child.Stdin, child.Stdout = stdin, &out
+
+	pty, err := newExecPty(DefaultConfig(), child)
+	if err != nil {
+		t.Fatalf("unable to open pty: %s", err)
+	}
+	if err := child.Run(); err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		pty.finish()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("finish is stuck waiting on the stdin copy")
+	}
+
+	if out.String() != "hi\r\n" {
+		t.Errorf("expected the command's output but got %q", out.String())
+	}
+}
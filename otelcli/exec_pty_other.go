@@ -0,0 +1,29 @@
+//go:build !linux
+
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// execResizeSignals is empty, there's no pty to resize.
+var execResizeSignals = []os.Signal{}
+
+// openExecPty always fails, only Linux is supported for now.
+func openExecPty() (*os.File, *os.File, error) {
+	return nil, nil, fmt.Errorf("--pty is not supported on %s", runtime.GOOS)
+}
+
+// execPtyAttach is never called since openExecPty always fails.
+func execPtyAttach(child *exec.Cmd, tty *os.File) {}
+
+// execPollInput is never called since openExecPty always fails.
+func execPollInput(f *os.File, timeoutMs int) (bool, error) {
+	return true, nil
+}
+
+// resize does nothing.
+func (p *execPty) resize() {}
@@ -0,0 +1,213 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	"github.com/tobert/otel-cli/otlpserver"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// execRelayProtocols maps --relay to the OTEL_EXPORTER_OTLP_PROTOCOL the
+// command is told to use.
+var execRelayProtocols = map[string]string{
+	"grpc": "grpc",
+	"http": "http/protobuf",
+}
+
+// execRelay is a local OTLP server for the command and everything it starts.
+// Spans, logs, and metrics sent to it are forwarded upstream using otel-cli's endpoint, TLS,
+// and headers, so only otel-cli needs network access and credentials.
+type execRelay struct {
+	config   Config
+	ctx      context.Context
+	client   otlpclient.OTLPClient
+	server   otlpserver.OtlpServer
+	endpoint string // given to the command as OTEL_EXPORTER_OTLP_ENDPOINT
+	protocol string // given to the command as OTEL_EXPORTER_OTLP_PROTOCOL
+	cleanup  func()
+	traceId  []byte
+
+	// only touched by the server's pipeline until finish
+	spans, unrelated, failed int64
+	logs, failedLogs         int64
+	metrics, failedMetrics   int64
+}
+
+// newExecRelay starts the relay according to --relay and --relay-listen.
+// Returns nil when it's disabled.
+func newExecRelay(ctx context.Context, config Config, span *tracev1.Span) (*execRelay, error) {
+	if config.ExecRelay == "" {
+		return nil, nil
+	}
+	protocol, ok := execRelayProtocols[config.ExecRelay]
+	if !ok {
+		return nil, fmt.Errorf("invalid --relay %q, must be grpc or http", config.ExecRelay)
+	}
+
+	listener, endpoint, cleanup, err := execRelayListen(config.ExecRelayListen)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on --relay-listen %q: %w", config.ExecRelayListen, err)
+	}
+
+	er := execRelay{
+		config:   config,
+		endpoint: endpoint,
+		protocol: protocol,
+		cleanup:  cleanup,
+		traceId:  span.TraceId,
+	}
+	er.ctx, er.client = StartClient(ctx, config)
+	er.server = otlpserver.NewServer(config.ExecRelay, er.countSpan, func(otlpserver.OtlpServer) {})
+	er.server.SetRequestCallback(er.forward)
+
+	go func() {
+		if err := er.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			config.SoftLog("relay: %s", err)
+		}
+	}()
+
+	return &er, nil
+}
+
+// execRelayListen opens the relay's listener and returns it with the
+// endpoint the command should send to. The address is a host:port, a
+// unix:///path URL, or just unix for a socket in a private temp directory.
+func execRelayListen(addr string) (net.Listener, string, func(), error) {
+	cleanup := func() {}
+	if addr == "unix" {
+		dir, err := os.MkdirTemp("", "otel-cli-")
+		if err != nil {
+			return nil, "", cleanup, err
+		}
+		addr = "unix://" + filepath.Join(dir, "otlp.sock")
+		cleanup = func() { os.RemoveAll(dir) }
+	}
+
+	if sockfile, ok := strings.CutPrefix(addr, "unix://"); ok {
+		listener, err := net.Listen("unix", sockfile)
+		if err != nil {
+			cleanup()
+		}
+		return listener, addr, cleanup, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", cleanup, err
+	}
+	// a scheme tells SDKs to skip TLS for gRPC too
+	return listener, "http://" + listener.Addr().String(), cleanup, nil
+}
+
+// childEnv replaces any OTLP exporter settings in env with the relay's
+// endpoint and protocol, leaving headers and certificates out of the
+// command's reach. Returns env unchanged when there's no relay.
+func (er *execRelay) childEnv(env []string) []string {
+	if er == nil {
+		return env
+	}
+
+	out := make([]string, 0, len(env)+2)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "OTEL_EXPORTER_OTLP_") {
+			out = append(out, kv)
+		}
+	}
+
+	return append(out,
+		"OTEL_EXPORTER_OTLP_ENDPOINT="+er.endpoint,
+		"OTEL_EXPORTER_OTLP_PROTOCOL="+er.protocol,
+	)
+}
+
+// countSpan is the server's span callback, counting spans and the ones that
+// aren't in the exec span's trace.
+func (er *execRelay) countSpan(ctx context.Context, span *tracev1.Span, events []*tracev1.Span_Event, rss *tracev1.ResourceSpans, headers map[string]string, meta map[string]string) bool {
+	er.spans++
+	if !bytes.Equal(span.TraceId, er.traceId) {
+		er.unrelated++
+	}
+	return false
+}
+
+// forward is the server's request callback. It's called once for each
+// request of any signal, which is sent upstream as-is. Returns false so the
+// span callback still sees each span.
+func (er *execRelay) forward(ctx context.Context, req proto.Message, headers map[string]string, meta map[string]string) bool {
+	// --timeout applies to each forwarded request
+	ctx, cancel := context.WithTimeout(er.ctx, er.config.GetTimeout())
+	defer cancel()
+
+	switch req := req.(type) {
+	case *coltracepb.ExportTraceServiceRequest:
+		if _, err := er.client.UploadTraces(ctx, req.GetResourceSpans()); err != nil {
+			for _, rss := range req.GetResourceSpans() {
+				for _, ss := range rss.GetScopeSpans() {
+					er.failed += int64(len(ss.GetSpans()))
+				}
+			}
+			er.config.SoftLog("relay: unable to forward spans: %s", err)
+		}
+	case *collogspb.ExportLogsServiceRequest:
+		var count int64
+		for _, rls := range req.GetResourceLogs() {
+			for _, sl := range rls.GetScopeLogs() {
+				count += int64(len(sl.GetLogRecords()))
+			}
+		}
+		er.logs += count
+		if _, err := er.client.UploadLogs(ctx, req.GetResourceLogs()); err != nil {
+			er.failedLogs += count
+			er.config.SoftLog("relay: unable to forward log records: %s", err)
+		}
+	case *colmetricspb.ExportMetricsServiceRequest:
+		var count int64
+		for _, rms := range req.GetResourceMetrics() {
+			for _, sm := range rms.GetScopeMetrics() {
+				count += int64(len(sm.GetMetrics()))
+			}
+		}
+		er.metrics += count
+		if _, err := er.client.UploadMetrics(ctx, req.GetResourceMetrics()); err != nil {
+			er.failedMetrics += count
+			er.config.SoftLog("relay: unable to forward metrics: %s", err)
+		}
+	}
+
+	return false
+}
+
+// finish shuts the relay down once the command has exited, after
+// forwarding everything it already received, and returns attributes for the
+// exec span counting what was relayed.
+func (er *execRelay) finish() []*commonpb.KeyValue {
+	er.server.StopWait()
+	er.cleanup()
+	if _, err := er.client.Stop(er.ctx); err != nil {
+		er.config.SoftLog("relay: client.Stop() failed: %s", err)
+	}
+
+	return []*commonpb.KeyValue{
+		int64Attr("exec.relay.spans", er.spans),
+		int64Attr("exec.relay.unrelated_spans", er.unrelated),
+		int64Attr("exec.relay.failed_spans", er.failed),
+		int64Attr("exec.relay.log_records", er.logs),
+		int64Attr("exec.relay.failed_log_records", er.failedLogs),
+		int64Attr("exec.relay.metrics", er.metrics),
+		int64Attr("exec.relay.failed_metrics", er.failedMetrics),
+	}
+}
@@ -0,0 +1,55 @@
+package otelcli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecRelayListen(t *testing.T) {
+	listener, endpoint, cleanup, err := execRelayListen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("tcp listen failed: %s", err)
+	}
+	if endpoint != "http://"+listener.Addr().String() {
+		t.Errorf("unexpected tcp endpoint %q", endpoint)
+	}
+	listener.Close()
+	cleanup()
+
+	listener, endpoint, cleanup, err = execRelayListen("unix")
+	if err != nil {
+		t.Fatalf("unix listen failed: %s", err)
+	}
+	if !strings.HasPrefix(endpoint, "unix://") || !strings.HasSuffix(endpoint, "/otlp.sock") {
+		t.Errorf("unexpected unix endpoint %q", endpoint)
+	}
+	listener.Close()
+	cleanup()
+}
+
+func TestExecRelayChildEnv(t *testing.T) {
+	env := []string{
+		"TRACEPARENT=00-00000000000000000000000000000001-0000000000000001-01",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=https://collector.example.com",
+		"OTEL_EXPORTER_OTLP_HEADERS=authorization=secret",
+		"OTEL_SERVICE_NAME=test",
+	}
+
+	var none *execRelay
+	if diff := cmp.Diff(env, none.childEnv(env)); diff != "" {
+		t.Errorf("env should be unchanged without a relay (-want +got):\n%s", diff)
+	}
+
+	relay := execRelay{endpoint: "http://127.0.0.1:4317", protocol: "grpc"}
+	want := []string{
+		"TRACEPARENT=00-00000000000000000000000000000001-0000000000000001-01",
+		"OTEL_SERVICE_NAME=test",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=http://127.0.0.1:4317",
+		"OTEL_EXPORTER_OTLP_PROTOCOL=grpc",
+	}
+	if diff := cmp.Diff(want, relay.childEnv(env)); diff != "" {
+		t.Errorf("relay env didn't match (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,251 @@
+package otelcli
+
+import (
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// execRemoteLaunchers maps the commands --remote knows to the style of
+// launcher they are, and is extended by --remote-launchers.
+var execRemoteLaunchers = map[string]string{
+	"ssh":     "ssh",
+	"docker":  "docker",
+	"podman":  "docker",
+	"kubectl": "kubectl",
+	"oc":      "kubectl",
+}
+
+// execRemote rewrites a command line that runs a command somewhere else,
+// e.g. ssh host ..., so vars (KEY=value) end up in the remote command's
+// environment. Returns the args, unchanged when the launcher isn't known or
+// there's no way to reach the remote command, and attributes describing
+// where it runs.
+//
+//	ssh:     ssh [options] host env KEY=value command...
+//	docker:  docker run|exec|create -e KEY=value [options] ...
+//	kubectl: kubectl exec [options] pod -- env KEY=value command...
+func execRemote(config Config, args []string, vars []string) ([]string, []*commonpb.KeyValue) {
+	style := config.ExecRemoteLaunchers[filepath.Base(args[0])]
+	if style == "" {
+		style = execRemoteLaunchers[filepath.Base(args[0])]
+	}
+
+	var out []string
+	var attrs []*commonpb.KeyValue
+	switch style {
+	case "ssh":
+		out, attrs = execRemoteSsh(args, vars)
+	case "docker":
+		out, attrs = execRemoteDocker(args, vars)
+	case "kubectl":
+		out, attrs = execRemoteKubectl(args, vars)
+	case "":
+		return args, nil
+	default:
+		config.SoftFail("invalid --remote-launchers style %q for %s, must be ssh, docker, or kubectl", style, args[0])
+		return args, nil
+	}
+
+	if out == nil {
+		config.SoftLog("--remote: can't find where to add the environment in %q, running it as-is", strings.Join(args, " "))
+		return args, attrs
+	}
+	return out, append(attrs, stringAttr("exec.remote.launcher", style))
+}
+
+// execRemoteVars returns the variables --remote passes along: TRACEPARENT
+// as it was given to the command in env, TRACESTATE when there is one, and
+// BAGGAGE with --remote-baggage. Returns none when there's no traceparent.
+func execRemoteVars(config Config, env []string, template execTemplate) []string {
+	vars := []string{}
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "TRACEPARENT=") {
+			vars = append(vars, kv)
+		}
+	}
+	if len(vars) == 0 {
+		return vars
+	}
+
+	if template.tracestate != "" {
+		vars = append(vars, "TRACESTATE="+template.tracestate)
+	}
+	if config.ExecRemoteBaggage && template.baggage != "" {
+		vars = append(vars, "BAGGAGE="+template.baggage)
+	}
+	return vars
+}
+
+// sshOptionsWithValues are the ssh options that take an argument.
+const sshOptionsWithValues = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// execRemoteSsh puts env and the vars, quoted for the remote shell, between
+// the destination and the command. An interactive ssh with no command has
+// nowhere to put them.
+func execRemoteSsh(args []string, vars []string) ([]string, []*commonpb.KeyValue) {
+	var port string
+	dest := -1
+	for i := 1; i < len(args) && dest < 0; i++ {
+		arg := args[i]
+		if arg == "--" {
+			dest = i + 1
+			break
+		} else if !strings.HasPrefix(arg, "-") || arg == "-" {
+			dest = i
+			break
+		}
+
+		// options can be bundled, e.g. -vp 2222 or -p2222
+		for j := 1; j < len(arg); j++ {
+			if !strings.ContainsRune(sshOptionsWithValues, rune(arg[j])) {
+				continue
+			}
+			value := arg[j+1:]
+			if value == "" && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if arg[j] == 'p' {
+				port = value
+			}
+			break
+		}
+	}
+	if dest < 0 || dest >= len(args) {
+		return nil, nil
+	}
+
+	// ssh://user@host:port or user@host
+	host := args[dest]
+	if u, err := url.Parse(host); err == nil && u.Scheme == "ssh" {
+		host = u.Hostname()
+		if u.Port() != "" {
+			port = u.Port()
+		}
+	} else if _, h, found := strings.Cut(host, "@"); found {
+		host = h
+	}
+	attrs := []*commonpb.KeyValue{stringAttr("net.peer.name", host)}
+	if p, err := strconv.ParseInt(port, 10, 64); err == nil {
+		attrs = append(attrs, int64Attr("net.peer.port", p))
+	}
+
+	if dest+1 >= len(args) {
+		return nil, attrs
+	}
+
+	out := slices.Clone(args[:dest+1])
+	out = append(out, "env")
+	for _, v := range vars {
+		out = append(out, shellQuote(v))
+	}
+	return append(out, args[dest+1:]...), attrs
+}
+
+// dockerBoolOptions are the docker run, create, and exec options that
+// don't take a value. Everything else without an = is assumed to.
+var dockerBoolOptions = []string{
+	"-d", "--detach", "-i", "--interactive", "-t", "--tty", "--rm", "-P",
+	"--publish-all", "--privileged", "--init", "--read-only", "-q", "--quiet",
+	"--no-healthcheck", "--oom-kill-disable", "--sig-proxy", "--help",
+}
+
+// execRemoteDocker adds -e flags for the vars right after the run, create,
+// or exec subcommand.
+func execRemoteDocker(args []string, vars []string) ([]string, []*commonpb.KeyValue) {
+	sub := -1
+	for i, arg := range args[1:] {
+		if arg == "run" || arg == "create" || arg == "exec" {
+			sub = i + 1
+			break
+		}
+	}
+	if sub < 0 {
+		return nil, nil
+	}
+
+	// the first argument that isn't an option is the image or container
+	var attrs []*commonpb.KeyValue
+	for i := sub + 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			if args[sub] == "exec" {
+				attrs = append(attrs, stringAttr("container.name", arg))
+			} else {
+				attrs = append(attrs, stringAttr("container.image.name", arg))
+			}
+			break
+		} else if arg == "--" {
+			continue
+		}
+
+		isBool := slices.Contains(dockerBoolOptions, arg) ||
+			// bundled short options, e.g. -it
+			(len(arg) > 2 && arg[1] != '-' && strings.Trim(arg[1:], "dit") == "")
+		if name, value, found := strings.Cut(arg, "="); found {
+			if name == "--name" {
+				attrs = append(attrs, stringAttr("container.name", value))
+			}
+		} else if !isBool && i+1 < len(args) {
+			i++
+			if arg == "--name" {
+				attrs = append(attrs, stringAttr("container.name", args[i]))
+			}
+		}
+	}
+
+	out := slices.Clone(args[:sub+1])
+	for _, v := range vars {
+		out = append(out, "-e", v)
+	}
+	return append(out, args[sub+1:]...), attrs
+}
+
+// kubectlBoolOptions are the kubectl exec options that don't take a value.
+var kubectlBoolOptions = []string{"-i", "--stdin", "-t", "--tty", "-q", "--quiet", "-it", "-ti"}
+
+// execRemoteKubectl puts env and the vars right after the -- that starts
+// the command kubectl exec runs in the pod.
+func execRemoteKubectl(args []string, vars []string) ([]string, []*commonpb.KeyValue) {
+	sub := slices.Index(args, "exec")
+	dashes := slices.Index(args, "--")
+	if sub < 0 || dashes < sub {
+		return nil, nil
+	}
+
+	var attrs []*commonpb.KeyValue
+	var pod string
+	for i := 1; i < dashes; i++ {
+		arg := args[i]
+		name, value, found := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") {
+			if i > sub && pod == "" {
+				pod = strings.TrimPrefix(strings.TrimPrefix(arg, "pods/"), "pod/")
+			}
+			continue
+		} else if !found && !slices.Contains(kubectlBoolOptions, arg) && i+1 < dashes {
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "-n", "--namespace":
+			attrs = append(attrs, stringAttr("k8s.namespace.name", value))
+		case "-c", "--container":
+			attrs = append(attrs, stringAttr("k8s.container.name", value))
+		}
+	}
+	if pod != "" {
+		attrs = append(attrs, stringAttr("k8s.pod.name", pod))
+	}
+
+	out := slices.Clone(args[:dashes+1])
+	out = append(out, "env")
+	out = append(out, vars...)
+	return append(out, args[dashes+1:]...), attrs
+}
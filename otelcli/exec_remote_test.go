@@ -0,0 +1,93 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tobert/otel-cli/otlpclient"
+)
+
+func TestExecRemote(t *testing.T) {
+	tp := "TRACEPARENT=00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01"
+	vars := []string{tp, "BAGGAGE=team=obs cli"}
+
+	for _, tc := range []struct {
+		name      string
+		launchers map[string]string
+		args      []string
+		wantArgs  []string
+		wantAttrs map[string]string
+	}{
+		{
+			name:     "ssh with options",
+			args:     []string{"ssh", "-vp", "2222", "-i", "key", "deploy@web1", "make", "deploy"},
+			wantArgs: []string{"ssh", "-vp", "2222", "-i", "key", "deploy@web1", "env", tp, "'BAGGAGE=team=obs cli'", "make", "deploy"},
+			wantAttrs: map[string]string{
+				"net.peer.name":        "web1",
+				"net.peer.port":        "2222",
+				"exec.remote.launcher": "ssh",
+			},
+		},
+		{
+			name:      "interactive ssh is left alone",
+			args:      []string{"/usr/bin/ssh", "ssh://web1:2200"},
+			wantArgs:  []string{"/usr/bin/ssh", "ssh://web1:2200"},
+			wantAttrs: map[string]string{"net.peer.name": "web1", "net.peer.port": "2200"},
+		},
+		{
+			name:     "docker run",
+			args:     []string{"docker", "--context", "prod", "run", "--rm", "-it", "-e", "A=b", "--name=job", "alpine:3", "env"},
+			wantArgs: []string{"docker", "--context", "prod", "run", "-e", tp, "-e", vars[1], "--rm", "-it", "-e", "A=b", "--name=job", "alpine:3", "env"},
+			wantAttrs: map[string]string{
+				"container.name":       "job",
+				"container.image.name": "alpine:3",
+				"exec.remote.launcher": "docker",
+			},
+		},
+		{
+			name:     "podman exec",
+			args:     []string{"podman", "exec", "-u", "root", "web", "ls"},
+			wantArgs: []string{"podman", "exec", "-e", tp, "-e", vars[1], "-u", "root", "web", "ls"},
+			wantAttrs: map[string]string{
+				"container.name":       "web",
+				"exec.remote.launcher": "docker",
+			},
+		},
+		{
+			name:     "kubectl exec",
+			args:     []string{"kubectl", "exec", "-it", "-n", "shop", "pod/cart-0", "-c", "app", "--", "ls"},
+			wantArgs: []string{"kubectl", "exec", "-it", "-n", "shop", "pod/cart-0", "-c", "app", "--", "env", tp, vars[1], "ls"},
+			wantAttrs: map[string]string{
+				"k8s.namespace.name":   "shop",
+				"k8s.container.name":   "app",
+				"k8s.pod.name":         "cart-0",
+				"exec.remote.launcher": "kubectl",
+			},
+		},
+		{
+			name:      "in-house wrapper",
+			launchers: map[string]string{"jump": "ssh"},
+			args:      []string{"jump", "bastion", "uptime"},
+			wantArgs:  []string{"jump", "bastion", "env", tp, "'BAGGAGE=team=obs cli'", "uptime"},
+			wantAttrs: map[string]string{"net.peer.name": "bastion", "exec.remote.launcher": "ssh"},
+		},
+		{
+			name:      "not a launcher",
+			args:      []string{"make", "exec", "--", "ssh"},
+			wantArgs:  []string{"make", "exec", "--", "ssh"},
+			wantAttrs: map[string]string{},
+		},
+	} {
+		config := DefaultConfig()
+		config.ExecRemoteLaunchers = tc.launchers
+		args, attrs := execRemote(config, tc.args, vars)
+		if diff := cmp.Diff(tc.wantArgs, args); diff != "" {
+			t.Errorf("[%s] args didn't match (-want +got):\n%s", tc.name, diff)
+		}
+		span := otlpclient.NewProtobufSpan()
+		span.Attributes = attrs
+		if diff := cmp.Diff(tc.wantAttrs, otlpclient.SpanAttributesToStringMap(span)); diff != "" {
+			t.Errorf("[%s] attributes didn't match (-want +got):\n%s", tc.name, diff)
+		}
+	}
+}
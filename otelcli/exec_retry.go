@@ -0,0 +1,147 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ParseExecRetryOn parses --retry-on, a comma-separated list of exit codes
+// and/or "timeout" that are worth retrying. An empty list retries on any
+// failure.
+func (c Config) ParseExecRetryOn() map[string]bool {
+	out, err := parseExecRetryOn(c.ExecRetryOn)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+func parseExecRetryOn(in string) (map[string]bool, error) {
+	out := make(map[string]bool)
+	for _, item := range strings.Split(in, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		} else if strings.EqualFold(item, execStatusMapTimeout) {
+			out[execStatusMapTimeout] = true
+		} else if _, err := strconv.Atoi(item); err == nil {
+			out[item] = true
+		} else {
+			return nil, fmt.Errorf("invalid --retry-on value %q, must be an exit code or %q", item, execStatusMapTimeout)
+		}
+	}
+	return out, nil
+}
+
+// execRetryDelay returns how long to wait after the given attempt (counting
+// from 1), doubling --retry-delay each time up to --retry-max-delay.
+func execRetryDelay(config Config, attempt int) time.Duration {
+	delay := config.ParseExecRetryDelay()
+	maxDelay := config.ParseExecRetryMaxDelay()
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// execShouldRetry decides if a finished attempt is worth trying again. It has
+// to have failed, after --status-map is applied, in a way that isn't going
+// to fix itself, and match --retry-on when that's set.
+func execShouldRetry(config Config, span *tracev1.Span, child *exec.Cmd, result execResult) bool {
+	if span.Status.GetCode() != tracev1.Status_STATUS_CODE_ERROR {
+		return false
+	} else if result.stopped || child.ProcessState == nil {
+		// the user asked to stop, or the command doesn't exist
+		return false
+	}
+
+	retryOn := config.ParseExecRetryOn()
+	if len(retryOn) == 0 {
+		return true
+	} else if result.timedOut {
+		return retryOn[execStatusMapTimeout]
+	}
+	return retryOn[strconv.Itoa(child.ProcessState.ExitCode())]
+}
+
+// execWithRetries runs the command up to --retries + 1 times, each attempt in
+// its own child span of span, which covers the whole thing and ends up with
+// the status and exit attributes of the last attempt. Returns the last
+// attempt's command along with the attempt spans and their children.
+func execWithRetries(config Config, span *tracev1.Span, args []string, relay *execRelay) (*exec.Cmd, []*tracev1.Span) {
+	span.StartTimeUnixNano = uint64(time.Now().UnixNano())
+
+	// a ^C between attempts should stop retrying rather than kill otel-cli
+	// before it can send the spans
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, execStopSignals...)
+	defer signal.Stop(stop)
+
+	var child *exec.Cmd
+	var spans []*tracev1.Span
+	var last *tracev1.Span
+	var attempts int
+retrying:
+	for attempt := 1; attempt <= config.ExecRetries+1; attempt++ {
+		last = otlpclient.NewProtobufSpan()
+		last.TraceId = span.TraceId
+		last.ParentSpanId = span.SpanId
+		if config.GetIsRecording() {
+			last.SpanId = otlpclient.GenerateSpanId()
+		}
+		last.Name = fmt.Sprintf("%s attempt %d", span.Name, attempt)
+		last.Kind = span.Kind
+		last.Attributes = append(last.Attributes, int64Attr("exec.retry.attempt", int64(attempt)))
+		spans = append(spans, last)
+
+		var result execResult
+		child, result = execAttempt(config, last, args, relay)
+		spans = append(spans, result.spans...)
+		attempts = attempt
+		if attempt > config.ExecRetries || !execShouldRetry(config, last, child, result) {
+			break
+		}
+
+		delay := execRetryDelay(config, attempt)
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = "exec.retry"
+		event.Attributes = append(event.Attributes,
+			int64Attr("exec.retry.attempt", int64(attempt)),
+			int64Attr("exec.retry.delay_ms", delay.Milliseconds()),
+		)
+		span.Events = append(span.Events, event)
+
+		select {
+		case <-time.After(delay):
+		case sig := <-stop:
+			event := otlpclient.NewProtobufSpanEvent()
+			event.Name = "exec.retry.cancelled"
+			event.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+				"signal.name": execSignalName(sig),
+			})
+			span.Events = append(span.Events, event)
+			break retrying
+		}
+	}
+
+	// the parent reports the final outcome, and the args the way the
+	// attempts recorded them per --record-args
+	span.Attributes = append(span.Attributes, int64Attr("exec.retry.attempts", int64(attempts)))
+	for _, attr := range last.Attributes {
+		if strings.HasPrefix(attr.Key, "process.exit.") || attr.Key == "process.command" || attr.Key == "process.command_args" {
+			span.Attributes = append(span.Attributes, attr)
+		}
+	}
+	if last.Status.GetCode() != tracev1.Status_STATUS_CODE_UNSET {
+		span.Status = last.Status
+	}
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+
+	return child, spans
+}
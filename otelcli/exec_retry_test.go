@@ -0,0 +1,41 @@
+package otelcli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseExecRetryOn(t *testing.T) {
+	got, err := parseExecRetryOn(" 1, 75,TIMEOUT,,")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expect := map[string]bool{"1": true, "75": true, "timeout": true}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("retry-on didn't match (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseExecRetryOn("1,sometimes"); err == nil {
+		t.Error("expected an error for a value that isn't an exit code")
+	}
+}
+
+func TestExecRetryDelay(t *testing.T) {
+	config := DefaultConfig()
+	config.ExecRetryDelay = "100ms"
+	config.ExecRetryMaxDelay = "1s"
+
+	for attempt, expect := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := execRetryDelay(config, attempt); got != expect {
+			t.Errorf("expected delay after attempt %d to be %s but got %s", attempt, expect, got)
+		}
+	}
+}
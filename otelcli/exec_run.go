@@ -0,0 +1,157 @@
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// execResult is what happened while running a child process.
+type execResult struct {
+	err      error                 // from Start() or Wait()
+	events   []*tracev1.Span_Event // signals, timeouts, and samples
+	timedOut bool                  // --command-timeout was reached
+	stopped  bool                  // otel-cli was asked to stop, e.g. ^C
+	spans    []*tracev1.Span       // child spans from the side channel
+}
+
+// execEventLog collects span events from the goroutines watching a child.
+type execEventLog struct {
+	mu     sync.Mutex
+	events []*tracev1.Span_Event
+}
+
+// add appends an event with the current time and string attributes.
+func (el *execEventLog) add(name string, attrs map[string]string) {
+	event := otlpclient.NewProtobufSpanEvent()
+	event.Name = name
+	event.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.events = append(el.events, event)
+}
+
+// runChild starts the child, forwards signals to it for as long as it runs,
+// enforces --command-timeout, and waits for it to exit. On timeout the
+// child's process group gets SIGTERM, then SIGKILL if it's still around
+// after --kill-grace. Every signal sent is recorded as a span event.
+// When otel-cli is in the foreground of a terminal, the child is given the
+// terminal while it runs.
+func runChild(config Config, child *exec.Cmd) execResult {
+	return runChildUntil(config, child, nil, true)
+}
+
+// runChildUntil is runChild, but when cancel is closed the child is stopped
+// the same way as on --command-timeout. The child is only given the terminal
+// when foreground is true, e.g. not for parallel jobs that would fight over it.
+func runChildUntil(config Config, child *exec.Cmd, cancel <-chan struct{}, foreground bool) execResult {
+	var result execResult
+	var events execEventLog
+
+	grouped, tty := execSetProcessGroup(child, foreground)
+	ownTerminal := execOwnsTerminal(child)
+
+	// start listening before the child starts so nothing slips through,
+	// which also keeps these signals from killing otel-cli before it can
+	// send the span
+	signals := make(chan os.Signal, 10)
+	signal.Notify(signals, execForwardSignals...)
+	defer signal.Stop(signals)
+
+	if result.err = child.Start(); result.err != nil {
+		return result
+	}
+	stopSampling := startProcSampler(config, child.Process.Pid)
+
+	sendSignal := func(sig os.Signal, reason string) {
+		attrs := map[string]string{
+			"signal.name":   execSignalName(sig),
+			"signal.reason": reason,
+		}
+		if err := execSignalGroup(child, sig, grouped); err != nil {
+			attrs["error"] = err.Error()
+		}
+		events.add("process.signal", attrs)
+	}
+
+	exited := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case sig := <-signals:
+				if slices.Contains(execStopSignals, sig) {
+					result.stopped = true
+				}
+				if execShouldForward(sig, grouped, ownTerminal, tty >= 0) {
+					sendSignal(sig, "forwarded")
+				}
+			case <-exited:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		var expired <-chan time.Time // nil blocks forever, same as no timeout
+		timeout := config.ParseExecCommandTimeout()
+		if timeout > 0 {
+			expired = time.After(timeout)
+		}
+
+		select {
+		case <-expired:
+			result.timedOut = true
+			events.add("process.timeout", map[string]string{
+				"timeout.ms": strconv.FormatInt(timeout.Milliseconds(), 10),
+			})
+			sendSignal(execTermSignal, "timeout")
+		case <-cancel:
+			sendSignal(execTermSignal, "cancelled")
+		case <-exited:
+			return
+		}
+
+		select {
+		case <-time.After(config.ParseExecKillGrace()):
+			sendSignal(os.Kill, "escalation")
+		case <-exited:
+		}
+	}()
+
+	// with the terminal, ^C and ^Z go straight to the child
+	if tty >= 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			execFollowStops(child, tty)
+		}()
+	}
+
+	result.err = child.Wait()
+	close(exited)
+	wg.Wait()
+
+	if tty >= 0 {
+		execReclaimTerminal(tty, child)
+		// otel-cli never saw the ^C that ended the command, so it's noted here
+		if sig := execExitSignal(child.ProcessState); sig != nil && slices.Contains(execStopSignals, sig) {
+			result.stopped = true
+		}
+	}
+
+	result.events = append(events.events, stopSampling()...)
+	return result
+}
@@ -0,0 +1,169 @@
+//go:build linux
+
+package otelcli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// clockTicks is USER_HZ, the unit of the cpu times in /proc/<pid>/stat. Go
+// can't call sysconf(_SC_CLK_TCK) without cgo but it has been 100 on every
+// Linux architecture for a very long time.
+const clockTicks = 100
+
+// processRusageAttrs returns the child's resource usage from wait4(2) as
+// attributes ready to append to a protobuf span's span.Attributes.
+func processRusageAttrs(state *os.ProcessState) []*commonpb.KeyValue {
+	attrs := []*commonpb.KeyValue{
+		int64Attr("process.cpu.user_time_ms", state.UserTime().Milliseconds()),
+		int64Attr("process.cpu.system_time_ms", state.SystemTime().Milliseconds()),
+	}
+
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return attrs
+	}
+
+	// the Rusage fields are int32 on 32-bit platforms, hence all the casts
+	return append(attrs,
+		int64Attr("process.memory.max_rss_kb", int64(ru.Maxrss)),
+		int64Attr("process.paging.minor_faults", int64(ru.Minflt)),
+		int64Attr("process.paging.major_faults", int64(ru.Majflt)),
+		int64Attr("process.context_switches.voluntary", int64(ru.Nvcsw)),
+		int64Attr("process.context_switches.involuntary", int64(ru.Nivcsw)),
+		int64Attr("process.disk.read_blocks", int64(ru.Inblock)),
+		int64Attr("process.disk.write_blocks", int64(ru.Oublock)),
+	)
+}
+
+// startProcSampler reads /proc/<pid> every --sample-interval and turns each
+// reading into a span event, for watching long-running commands. Only the
+// direct child is sampled. Returns a func that stops sampling and returns
+// the events, which is a no-op when sampling is disabled.
+func startProcSampler(config Config, pid int) func() []*tracev1.Span_Event {
+	interval := config.ParseExecSampleInterval()
+	if interval <= 0 {
+		return func() []*tracev1.Span_Event { return nil }
+	}
+
+	events := []*tracev1.Span_Event{}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				attrs, err := readProcSample(fmt.Sprintf("/proc/%d", pid))
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ESRCH) {
+					// the process exited, nothing left to sample
+					return
+				} else if err != nil {
+					config.SoftLog("failed to sample process %d: %s", pid, err)
+					continue
+				}
+				event := otlpclient.NewProtobufSpanEvent()
+				event.Name = "process.sample"
+				event.Attributes = attrs
+				events = append(events, event)
+			}
+		}
+	}()
+
+	return func() []*tracev1.Span_Event {
+		close(stop)
+		<-done
+		return events
+	}
+}
+
+// readProcSample reads cpu, memory, and io counters from a /proc/<pid>
+// directory and returns them as attributes. io is skipped when unreadable
+// since /proc/<pid>/io needs ptrace access on some systems.
+func readProcSample(procdir string) ([]*commonpb.KeyValue, error) {
+	stat, err := os.ReadFile(procdir + "/stat")
+	if err != nil {
+		return nil, err
+	}
+	// the command name in field 2 can contain spaces and parens, so split
+	// after the last paren, which leaves state as the first field
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("unable to parse %s/stat", procdir)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 18 {
+		return nil, fmt.Errorf("expected at least 20 fields in %s/stat but got %d", procdir, len(fields)+2)
+	}
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	threads, _ := strconv.ParseInt(fields[17], 10, 64)
+
+	attrs := []*commonpb.KeyValue{
+		int64Attr("process.cpu.user_time_ms", utime*1000/clockTicks),
+		int64Attr("process.cpu.system_time_ms", stime*1000/clockTicks),
+		int64Attr("process.threads", threads),
+	}
+
+	status, err := readProcKeyValues(procdir + "/status")
+	if err != nil {
+		return nil, err
+	}
+	for _, mem := range []struct{ key, attr string }{
+		{"VmRSS", "process.memory.rss_kb"},
+		{"VmHWM", "process.memory.max_rss_kb"},
+	} {
+		// values look like "1234 kB"
+		if kb, err := strconv.ParseInt(strings.TrimSuffix(status[mem.key], " kB"), 10, 64); err == nil {
+			attrs = append(attrs, int64Attr(mem.attr, kb))
+		}
+	}
+
+	if pio, err := readProcKeyValues(procdir + "/io"); err == nil {
+		for _, key := range []string{"read_bytes", "write_bytes"} {
+			if val, err := strconv.ParseInt(pio[key], 10, 64); err == nil {
+				attrs = append(attrs, int64Attr("process.disk."+key, val))
+			}
+		}
+	}
+
+	return attrs, nil
+}
+
+// readProcKeyValues reads a /proc file made of "key: value" lines into a map.
+func readProcKeyValues(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	out := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), ":"); ok {
+			out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return out, scanner.Err()
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestReadProcSample(t *testing.T) {
+	procdir := t.TempDir()
+	files := map[string]string{
+		// command names can contain spaces and parens
+		"stat":   "4242 (my (cool) cmd) S 1 4242 4242 0 -1 4194304 100 0 0 0 250 50 0 0 20 0 3 0 12345 1000000 500 18446744073709551615\n",
+		"status": "Name:\tmy (cool) cmd\nVmHWM:\t    2048 kB\nVmRSS:\t    1024 kB\nThreads:\t3\n",
+		"io":     "rchar: 100\nwchar: 200\nread_bytes: 4096\nwrite_bytes: 8192\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(procdir, name), []byte(data), 0600); err != nil {
+			t.Fatalf("failed to write fake proc file: %s", err)
+		}
+	}
+
+	attrs, err := readProcSample(procdir)
+	if err != nil {
+		t.Fatalf("readProcSample failed: %s", err)
+	}
+
+	span := tracev1.Span{Attributes: attrs}
+	got := otlpclient.SpanAttributesToStringMap(&span)
+	expect := map[string]string{
+		"process.cpu.user_time_ms":   "2500",
+		"process.cpu.system_time_ms": "500",
+		"process.threads":            "3",
+		"process.memory.rss_kb":      "1024",
+		"process.memory.max_rss_kb":  "2048",
+		"process.disk.read_bytes":    "4096",
+		"process.disk.write_bytes":   "8192",
+	}
+	for k, v := range expect {
+		if got[k] != v {
+			t.Errorf("expected attribute %s to be %q but got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestReadProcSampleSelf(t *testing.T) {
+	// the real /proc has to parse too, and this process certainly uses memory
+	attrs, err := readProcSample("/proc/self")
+	if err != nil {
+		t.Fatalf("readProcSample on /proc/self failed: %s", err)
+	}
+	span := tracev1.Span{Attributes: attrs}
+	if otlpclient.SpanAttributesToStringMap(&span)["process.memory.rss_kb"] == "" {
+		t.Error("expected process.memory.rss_kb from /proc/self")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package otelcli
+
+import (
+	"os"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// processRusageAttrs returns the child's cpu times as attributes. The rest of
+// rusage is only reported on Linux, where the units are known.
+func processRusageAttrs(state *os.ProcessState) []*commonpb.KeyValue {
+	return []*commonpb.KeyValue{
+		int64Attr("process.cpu.user_time_ms", state.UserTime().Milliseconds()),
+		int64Attr("process.cpu.system_time_ms", state.SystemTime().Milliseconds()),
+	}
+}
+
+// startProcSampler is a no-op because /proc sampling is only supported on Linux.
+func startProcSampler(config Config, pid int) func() []*tracev1.Span_Event {
+	if config.ParseExecSampleInterval() > 0 {
+		config.SoftLog("--sample-interval is only supported on Linux")
+	}
+	return func() []*tracev1.Span_Event { return nil }
+}
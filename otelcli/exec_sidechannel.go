@@ -0,0 +1,219 @@
+package otelcli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// execSideChannelDrain is how long to keep reading records after the command
+// exits, in case it left something running that still has the pipe open.
+const execSideChannelDrain = 100 * time.Millisecond
+
+// execSideChannel reads records from the command over a pipe or FIFO and
+// applies them to the exec span. Records are one per line, either a JSON
+// object or key=value pairs like --attrs, with a type key saying what to do:
+//
+//	type=event,name=cache miss,key=users     add an event
+//	type=attrs,cache.hits=42                 set attributes
+//	type=status,code=error,description=oops  set the status
+//	type=start,name=migrate                  start a nested child span
+//	type=end,name=migrate,rows=1000          end it, name is optional
+//
+// Events, attributes, and status apply to the innermost open child span, or
+// the exec span when none are open. Any record can also have a time key, in
+// any format --start accepts.
+type execSideChannel struct {
+	config  Config
+	reader  *os.File
+	writer  *os.File // the child's end of the pipe when there is one
+	cleanup func()
+	spans   *spanStack
+	status  *tracev1.Status // set on the exec span by a status record
+	done    chan struct{}
+}
+
+// newExecSideChannel sets up the side channel for the child according to
+// --side-channel and starts reading it. Returns nil when it's disabled.
+func newExecSideChannel(config Config, span *tracev1.Span, child *exec.Cmd, appendChildEnv func(string, string)) (*execSideChannel, error) {
+	if config.ExecSideChannel == "" {
+		return nil, nil
+	} else if config.ExecSideChannel != "fd" && config.ExecSideChannel != "fifo" {
+		return nil, fmt.Errorf("invalid --side-channel %q, must be fd or fifo", config.ExecSideChannel)
+	}
+
+	sc := execSideChannel{
+		config:  config,
+		cleanup: func() {},
+		spans:   newSpanStack(config, span),
+		done:    make(chan struct{}),
+	}
+	if err := sc.open(config.ExecSideChannel, child, appendChildEnv); err != nil {
+		return nil, err
+	}
+
+	go sc.read()
+
+	return &sc, nil
+}
+
+// read applies records until the side channel is closed or times out.
+func (sc *execSideChannel) read() {
+	defer close(sc.done)
+
+	scanner := bufio.NewScanner(sc.reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		record, err := parseSideChannelRecord(scanner.Text())
+		if err == nil && record != nil {
+			err = sc.apply(record, time.Now())
+		}
+		if err != nil {
+			sc.config.SoftLog("side channel: %s", err)
+		}
+	}
+}
+
+// finish stops reading once the command has exited, giving it a moment to
+// drain, and returns the child spans along with any status set on the
+// exec span.
+func (sc *execSideChannel) finish() ([]*tracev1.Span, *tracev1.Status) {
+	if sc.writer != nil {
+		sc.writer.Close()
+	}
+	if err := sc.reader.SetReadDeadline(time.Now().Add(execSideChannelDrain)); err != nil {
+		sc.config.SoftLog("side channel: %s", err)
+	}
+	<-sc.done
+	sc.reader.Close()
+	sc.cleanup()
+
+	return sc.spans.finish("exec", time.Now()), sc.status
+}
+
+// parseSideChannelRecord parses a JSON object or key=value,key=value line into
+// a string map. Returns nil for blank lines and # comments.
+func parseSideChannelRecord(line string) (map[string]string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(line, "{") {
+		return parseCkvStringMap(line)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON record: %w", err)
+	}
+
+	// flatten to strings so StringMapAttrsToProtobuf picks the types, same
+	// as the key=value format
+	out := make(map[string]string, len(fields))
+	for key, value := range fields {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			out[key] = v
+		case float64:
+			out[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			out[key] = strconv.FormatBool(v)
+		default:
+			js, _ := json.Marshal(v)
+			out[key] = string(js)
+		}
+	}
+	return out, nil
+}
+
+// apply does what a record says. The record is modified.
+func (sc *execSideChannel) apply(record map[string]string, ts time.Time) error {
+	kind := record["type"]
+	delete(record, "type")
+
+	if when, ok := record["time"]; ok {
+		var err error
+		if ts, err = sc.config.parseTime(when, "record"); err != nil {
+			return err
+		}
+		delete(record, "time")
+	}
+
+	name, hasName := record["name"]
+	delete(record, "name")
+
+	switch kind {
+	case "event":
+		if name == "" {
+			return fmt.Errorf("event records need a name")
+		}
+		event := otlpclient.NewProtobufSpanEvent()
+		event.TimeUnixNano = uint64(ts.UnixNano())
+		event.Name = name
+		event.Attributes = otlpclient.StringMapAttrsToProtobuf(record)
+		span := sc.spans.current()
+		span.Events = append(span.Events, event)
+	case "attrs":
+		setSpanAttributes(sc.spans.current(), record)
+	case "status":
+		code := record["code"]
+		if code != "ok" && code != "error" && code != "unset" {
+			return fmt.Errorf("invalid status code %q, must be one of ok, error, or unset", code)
+		}
+		status := &tracev1.Status{
+			Code:    otlpclient.SpanStatusStringToInt(code),
+			Message: record["description"],
+		}
+		if span := sc.spans.current(); span != sc.spans.root {
+			span.Status = status
+		} else {
+			sc.status = status
+		}
+	case "start":
+		if name == "" {
+			return fmt.Errorf("start records need a name")
+		}
+		span := sc.spans.start(name, 0, ts)
+		span.Attributes = otlpclient.StringMapAttrsToProtobuf(record)
+	case "end":
+		span := sc.spans.end(func(open stackedSpan) bool {
+			return !hasName || open.span.Name == name
+		}, ts)
+		if span == nil {
+			return fmt.Errorf("no open span to end named %q", name)
+		}
+		setSpanAttributes(span, record)
+	default:
+		return fmt.Errorf("unknown record type %q", kind)
+	}
+
+	return nil
+}
+
+// setSpanAttributes sets attributes on the span, replacing any with the
+// same key.
+func setSpanAttributes(span *tracev1.Span, attrs map[string]string) {
+	for _, attr := range otlpclient.StringMapAttrsToProtobuf(attrs) {
+		replaced := false
+		for i, existing := range span.Attributes {
+			if existing.Key == attr.Key {
+				span.Attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			span.Attributes = append(span.Attributes, attr)
+		}
+	}
+}
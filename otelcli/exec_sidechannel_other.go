@@ -0,0 +1,14 @@
+//go:build !unix
+
+package otelcli
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// open always fails, passing files to children and FIFOs need unix.
+func (sc *execSideChannel) open(mode string, child *exec.Cmd, appendChildEnv func(string, string)) error {
+	return fmt.Errorf("--side-channel is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,137 @@
+package otelcli
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tobert/otel-cli/otlpclient"
+)
+
+func TestParseSideChannelRecord(t *testing.T) {
+	for _, tc := range []struct {
+		line   string
+		want   map[string]string
+		wantOk bool
+	}{
+		{line: "", want: nil, wantOk: true},
+		{line: "  # a comment", want: nil, wantOk: true},
+		{
+			line:   "type=event,name=cache miss,key=users",
+			want:   map[string]string{"type": "event", "name": "cache miss", "key": "users"},
+			wantOk: true,
+		},
+		{
+			line:   `{"type":"attrs","hits":42,"ratio":0.5,"warm":true,"gone":null,"tags":["a"]}`,
+			want:   map[string]string{"type": "attrs", "hits": "42", "ratio": "0.5", "warm": "true", "tags": `["a"]`},
+			wantOk: true,
+		},
+		{line: `{"type":`, wantOk: false},
+		{line: "type", wantOk: false},
+	} {
+		got, err := parseSideChannelRecord(tc.line)
+		if (err == nil) != tc.wantOk {
+			t.Errorf("%q: unexpected error result: %v", tc.line, err)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%q: records didn't match (-want +got):\n%s", tc.line, diff)
+		}
+	}
+}
+
+func TestExecSideChannelApply(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	root := config.NewProtobufSpan()
+	sc := execSideChannel{config: config, spans: newSpanStack(config, root)}
+
+	ts := time.Now()
+	for _, line := range []string{
+		"type=event,name=begin",
+		"type=start,name=outer,step=1",
+		"type=start,name=inner",
+		"type=status,code=error,description=inner failed",
+		"type=end,name=outer,rows=5",
+		"type=attrs,done=true",
+		"type=status,code=ok",
+	} {
+		ts = ts.Add(time.Second)
+		record, err := parseSideChannelRecord(line)
+		if err != nil {
+			t.Fatalf("%q: parse failed: %s", line, err)
+		}
+		if err := sc.apply(record, ts); err != nil {
+			t.Fatalf("%q: apply failed: %s", line, err)
+		}
+	}
+
+	for _, line := range []string{
+		"type=nope",
+		"type=event",
+		"type=start",
+		"type=end",
+		"type=status,code=bad",
+		"type=event,name=x,time=not a time",
+	} {
+		record, _ := parseSideChannelRecord(line)
+		if err := sc.apply(record, ts); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+
+	spans, status := sc.spans.finish("exec", ts), sc.status
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 child spans but got %d", len(spans))
+	}
+	inner, outer := spans[0], spans[1]
+	if inner.Name != "inner" || outer.Name != "outer" {
+		t.Errorf("expected inner then outer but got %q, %q", inner.Name, outer.Name)
+	}
+	if string(inner.ParentSpanId) != string(outer.SpanId) || string(outer.ParentSpanId) != string(root.SpanId) {
+		t.Error("child spans weren't nested under the root")
+	}
+	if inner.Status.GetMessage() != "inner failed" {
+		t.Errorf("expected the inner span status to be set but got %v", inner.Status)
+	}
+	if diff := cmp.Diff(map[string]string{"step": "1", "rows": "5"}, otlpclient.SpanAttributesToStringMap(outer)); diff != "" {
+		t.Errorf("outer attributes didn't match (-want +got):\n%s", diff)
+	}
+	if got := otlpclient.SpanAttributesToStringMap(root)["done"]; got != "true" {
+		t.Errorf("expected done=true on the root span but got %q", got)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != "begin" {
+		t.Errorf("expected a begin event on the root span but got %v", root.Events)
+	}
+	if status == nil || status.Code != otlpclient.SpanStatusStringToInt("ok") {
+		t.Errorf("expected an ok status for the exec span but got %v", status)
+	}
+}
+
+// TestExecSideChannelRace runs a command that writes to the side channel
+// right up until it exits, which used to race with execAttempt setting the
+// exec span's attributes and status. Run with -race.
+func TestExecSideChannelRace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the side channel needs a unix shell")
+	}
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	config.ExecSideChannel = "fd"
+	span := config.NewProtobufSpan()
+
+	script := `i=0; while [ $i -lt 200 ]; do echo "type=event,name=e$i" >&$OTEL_CLI_FD; echo "type=attrs,last=$i" >&$OTEL_CLI_FD; i=$((i+1)); done`
+	child, _ := execAttempt(config, span, []string{"/bin/sh", "-c", script}, nil)
+	if child.ProcessState == nil || !child.ProcessState.Success() {
+		t.Fatalf("the command failed: %v", child.ProcessState)
+	}
+
+	// every record written before the command exited is on the span
+	if len(span.Events) != 200 {
+		t.Errorf("expected 200 events from the side channel but got %d", len(span.Events))
+	}
+	if got := otlpclient.SpanAttributesToStringMap(span)["last"]; got != "199" {
+		t.Errorf("expected last=199 from the side channel but got %q", got)
+	}
+}
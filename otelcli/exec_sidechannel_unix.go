@@ -0,0 +1,50 @@
+//go:build unix
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// open creates the pipe or FIFO and tells the child where it is with
+// OTEL_CLI_FD or OTEL_CLI_FIFO.
+func (sc *execSideChannel) open(mode string, child *exec.Cmd, appendChildEnv func(string, string)) error {
+	if mode == "fd" {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		sc.reader, sc.writer = r, w
+		child.ExtraFiles = append(child.ExtraFiles, w)
+		// ExtraFiles start after stdin, stdout, and stderr
+		appendChildEnv("OTEL_CLI_FD", strconv.Itoa(2+len(child.ExtraFiles)))
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "otel-cli-")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "side-channel")
+	if err := unix.Mkfifo(path, 0600); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	// opening it read-write doesn't block waiting for a writer and never
+	// sees EOF, so the command can open and close it as often as it likes
+	sc.reader, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	sc.cleanup = func() { os.RemoveAll(dir) }
+	appendChildEnv("OTEL_CLI_FIFO", path)
+
+	return nil
+}
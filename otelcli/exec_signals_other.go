@@ -0,0 +1,53 @@
+//go:build !unix
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+)
+
+// execForwardSignals is every signal otel-cli exec passes along to the child.
+var execForwardSignals = []os.Signal{os.Interrupt}
+
+// execStopSignals are the forwarded signals that mean otel-cli should wrap
+// up rather than e.g. retry the command.
+var execStopSignals = []os.Signal{os.Interrupt}
+
+// execTermSignal is sent to the child on --command-timeout. There's no
+// gentler option that works everywhere, so this is the same as SIGKILL.
+var execTermSignal os.Signal = os.Kill
+
+// execSetProcessGroup does nothing where process groups aren't available.
+func execSetProcessGroup(child *exec.Cmd, foreground bool) (bool, int) {
+	return false, -1
+}
+
+// execReclaimTerminal is never called since there's no terminal to hand over.
+func execReclaimTerminal(tty int, child *exec.Cmd) {}
+
+// execOwnsTerminal always returns false, --pty isn't available.
+func execOwnsTerminal(child *exec.Cmd) bool {
+	return false
+}
+
+// execShouldForward always returns true, there are no process groups to
+// deliver signals twice.
+func execShouldForward(sig os.Signal, grouped, ownTerminal, foreground bool) bool {
+	return true
+}
+
+// execSignalGroup sends a signal to the child, since there's no group.
+func execSignalGroup(child *exec.Cmd, sig os.Signal, grouped bool) error {
+	return child.Process.Signal(sig)
+}
+
+// execSignalName returns the name of a signal.
+func execSignalName(sig os.Signal) string {
+	return sig.String()
+}
+
+// execExitSignal always returns nil, processes don't die of signals here.
+func execExitSignal(state *os.ProcessState) os.Signal {
+	return nil
+}
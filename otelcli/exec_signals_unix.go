@@ -0,0 +1,140 @@
+//go:build unix
+
+package otelcli
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// execForwardSignals is every signal otel-cli exec passes along to the child.
+// Job control signals (TSTP/TTIN/TTOU) are left alone so ^Z still works,
+// along with CHLD, PIPE, and URG which the Go runtime needs for itself.
+var execForwardSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGALRM,
+	syscall.SIGWINCH,
+	syscall.SIGCONT,
+}
+
+// execTerminalSignals are sent by the terminal to every process in the
+// foreground process group, so when the child shares otel-cli's group it
+// already has them and forwarding would deliver them twice.
+var execTerminalSignals = []os.Signal{syscall.SIGINT, syscall.SIGQUIT, syscall.SIGWINCH}
+
+// execStopSignals are the forwarded signals that mean otel-cli should wrap
+// up rather than e.g. retry the command.
+var execStopSignals = []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM}
+
+// execTermSignal is sent to the child's process group on --command-timeout,
+// before escalating to SIGKILL.
+var execTermSignal os.Signal = syscall.SIGTERM
+
+// execSetProcessGroup puts the child in its own process group so signals and
+// timeouts reach its whole tree, not just the direct child. When foreground
+// is set and otel-cli is the foreground job of a terminal, the child's group
+// is given the terminal so it can read it and gets ^C and ^Z itself. Where
+// execFollowStops can't keep ^Z working, the child stays in otel-cli's group
+// in that case instead. Returns true when the child gets its own group, and
+// the terminal's fd when it's handed over, otherwise -1.
+func execSetProcessGroup(child *exec.Cmd, foreground bool) (bool, int) {
+	// a child in its own session, e.g. on a --pty, already leads its own group
+	if child.SysProcAttr != nil && child.SysProcAttr.Setsid {
+		return true, -1
+	}
+
+	tty := execForegroundTerminal()
+	if tty >= 0 && !execCanFollowStops {
+		return false, -1
+	} else if !foreground {
+		tty = -1
+	}
+
+	child.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: tty >= 0, Ctty: tty}
+	return true, tty
+}
+
+// execForegroundTerminal returns the fd of otel-cli's stdin when it's a
+// terminal with otel-cli in its foreground process group, otherwise -1.
+func execForegroundTerminal() int {
+	fd := int(os.Stdin.Fd())
+	fgpgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil || fgpgrp != unix.Getpgrp() {
+		return -1
+	}
+	return fd
+}
+
+// execReclaimTerminal makes otel-cli's process group the foreground of the
+// terminal again, if the child's group still has it. SIGTTOU is ignored
+// while doing so, since otel-cli is in the background until it's done.
+func execReclaimTerminal(tty int, child *exec.Cmd) {
+	fgpgrp, err := unix.IoctlGetInt(tty, unix.TIOCGPGRP)
+	if err != nil || fgpgrp != child.Process.Pid {
+		return
+	}
+
+	if !signal.Ignored(syscall.SIGTTOU) {
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+	}
+	unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, unix.Getpgrp())
+}
+
+// execOwnsTerminal returns true when the child has a controlling terminal
+// of its own, i.e. --pty.
+func execOwnsTerminal(child *exec.Cmd) bool {
+	return child.SysProcAttr != nil && child.SysProcAttr.Setctty
+}
+
+// execShouldForward returns false for signals the child already received
+// from the terminal because it's in the same foreground process group, for
+// SIGWINCH when it has its own terminal, which sends SIGWINCH itself when
+// otel-cli resizes it, and for SIGCONT when it was handed otel-cli's
+// terminal, since execFollowStops continues it after giving the terminal back.
+func execShouldForward(sig os.Signal, grouped, ownTerminal, foreground bool) bool {
+	if ownTerminal && sig == syscall.SIGWINCH {
+		return false
+	} else if foreground && sig == syscall.SIGCONT {
+		return false
+	}
+	return grouped || !slices.Contains(execTerminalSignals, sig)
+}
+
+// execSignalGroup sends a signal to the child's whole process group, or
+// just the child when it shares otel-cli's group.
+func execSignalGroup(child *exec.Cmd, sig os.Signal, grouped bool) error {
+	if !grouped {
+		return child.Process.Signal(sig)
+	}
+	return syscall.Kill(-child.Process.Pid, sig.(syscall.Signal))
+}
+
+// execSignalName returns the conventional name for a signal, e.g. SIGTERM.
+func execSignalName(sig os.Signal) string {
+	if s, ok := sig.(syscall.Signal); ok {
+		if name := unix.SignalName(s); name != "" {
+			return name
+		}
+	}
+	return sig.String()
+}
+
+// execExitSignal returns the signal that terminated the child, or nil if it
+// exited on its own.
+func execExitSignal(state *os.ProcessState) os.Signal {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+	return nil
+}
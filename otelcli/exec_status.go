@@ -0,0 +1,142 @@
+package otelcli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// values for the process.exit.reason attribute on exec spans
+const (
+	execExitReasonExited      = "exited"
+	execExitReasonSignaled    = "signaled"
+	execExitReasonTimeout     = "timeout"
+	execExitReasonStartFailed = "start_failed"
+)
+
+// execStatusMapTimeout is the --status-map key for --command-timeout expiring.
+const execStatusMapTimeout = "timeout"
+
+var execSignalKeyRe = regexp.MustCompile(`^SIG[A-Z0-9]+$`)
+
+// execStatusRule is a parsed --status-map value.
+type execStatusRule struct {
+	code        tracev1.Status_StatusCode
+	description string
+}
+
+// ParseExecStatusMap parses --status-map, which maps exit codes, signal
+// names, or "timeout" to a span status with an optional description, e.g.
+// 1=ok,124=error:timed out,SIGPIPE=unset. Signal names are case insensitive
+// and the SIG prefix is optional.
+func (c Config) ParseExecStatusMap() map[string]execStatusRule {
+	out, err := parseExecStatusMap(c.ExecStatusMap)
+	c.SoftFailIfErr(err)
+	return out
+}
+
+func parseExecStatusMap(in map[string]string) (map[string]execStatusRule, error) {
+	out := make(map[string]execStatusRule, len(in))
+	for key, value := range in {
+		key = strings.TrimSpace(key)
+		if _, err := strconv.Atoi(key); err != nil {
+			if strings.EqualFold(key, execStatusMapTimeout) {
+				key = execStatusMapTimeout
+			} else {
+				key = strings.ToUpper(key)
+				if !strings.HasPrefix(key, "SIG") {
+					key = "SIG" + key
+				}
+				if !execSignalKeyRe.MatchString(key) {
+					return nil, fmt.Errorf("invalid --status-map key %q, must be an exit code, signal name, or %q", key, execStatusMapTimeout)
+				}
+			}
+		}
+
+		status, description, _ := strings.Cut(value, ":")
+		status = strings.ToLower(strings.TrimSpace(status))
+		if status != "ok" && status != "error" && status != "unset" {
+			return nil, fmt.Errorf("invalid --status-map status %q for %s, must be one of ok, error, or unset", status, key)
+		}
+
+		out[key] = execStatusRule{
+			code:        otlpclient.SpanStatusStringToInt(status),
+			description: description,
+		}
+	}
+
+	return out, nil
+}
+
+// execExitAttrs describes how the child finished with process.exit.code,
+// process.exit.signal, and process.exit.reason attributes, and returns the
+// span status for it. A nil status means the span's status should be left
+// alone, which is what happens when the command succeeds and --status-map
+// has nothing to say about it.
+func execExitAttrs(config Config, result execResult, state *os.ProcessState) ([]*commonpb.KeyValue, *tracev1.Status) {
+	rules := config.ParseExecStatusMap()
+	var attrs []*commonpb.KeyValue
+	var reason string
+	var ruleKeys []string // in order of precedence
+
+	if state == nil {
+		// the command never ran, e.g. not found or not executable
+		reason = execExitReasonStartFailed
+	} else if sig := execExitSignal(state); sig != nil {
+		reason = execExitReasonSignaled
+		attrs = append(attrs, stringAttr("process.exit.signal", execSignalName(sig)))
+		ruleKeys = append(ruleKeys, execSignalName(sig))
+	} else {
+		reason = execExitReasonExited
+		attrs = append(attrs, int64Attr("process.exit.code", int64(state.ExitCode())))
+		ruleKeys = append(ruleKeys, strconv.Itoa(state.ExitCode()))
+	}
+
+	// the timeout rule takes precedence over whatever signal enforced it
+	if result.timedOut {
+		reason = execExitReasonTimeout
+		ruleKeys = append([]string{execStatusMapTimeout}, ruleKeys...)
+	}
+	attrs = append(attrs, stringAttr("process.exit.reason", reason))
+
+	var failed string
+	if result.err != nil {
+		failed = fmt.Sprintf("exec command failed: %s", result.err)
+	}
+
+	for _, key := range ruleKeys {
+		if rule, ok := rules[key]; ok {
+			status := &tracev1.Status{Code: rule.code, Message: rule.description}
+			if rule.code == tracev1.Status_STATUS_CODE_ERROR && status.Message == "" {
+				status.Message = failed
+			}
+			return attrs, status
+		}
+	}
+
+	if result.err != nil {
+		return attrs, &tracev1.Status{
+			Message: failed,
+			Code:    tracev1.Status_STATUS_CODE_ERROR,
+		}
+	}
+
+	return attrs, nil
+}
+
+// stringAttr returns a string-valued attribute ready to append to a protobuf
+// span's span.Attributes.
+func stringAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key: key,
+		Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_StringValue{StringValue: value},
+		},
+	}
+}
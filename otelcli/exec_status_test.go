@@ -0,0 +1,59 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestParseExecStatusMap(t *testing.T) {
+	for _, tc := range []struct {
+		in     map[string]string
+		expect map[string]execStatusRule
+		fail   bool
+	}{
+		{
+			in:     map[string]string{},
+			expect: map[string]execStatusRule{},
+		},
+		{
+			in: map[string]string{
+				"1":       "ok",
+				"124":     "error:timed out",
+				"pipe":    "unset",
+				"SigTerm": "Error:stopped: by request",
+				"TIMEOUT": "error",
+			},
+			expect: map[string]execStatusRule{
+				"1":       {code: tracev1.Status_STATUS_CODE_OK},
+				"124":     {code: tracev1.Status_STATUS_CODE_ERROR, description: "timed out"},
+				"SIGPIPE": {code: tracev1.Status_STATUS_CODE_UNSET},
+				"SIGTERM": {code: tracev1.Status_STATUS_CODE_ERROR, description: "stopped: by request"},
+				"timeout": {code: tracev1.Status_STATUS_CODE_ERROR},
+			},
+		},
+		{
+			in:   map[string]string{"1": "fine"},
+			fail: true,
+		},
+		{
+			in:   map[string]string{"not a signal": "ok"},
+			fail: true,
+		},
+	} {
+		got, err := parseExecStatusMap(tc.in)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("expected an error parsing %v", tc.in)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("unexpected error parsing %v: %s", tc.in, err)
+		}
+
+		if diff := cmp.Diff(tc.expect, got, cmp.AllowUnexported(execStatusRule{})); diff != "" {
+			t.Errorf("status map didn't match (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package otelcli
+
+import (
+	"strings"
+
+	"github.com/tobert/otel-cli/w3c/traceparent"
+	"go.opentelemetry.io/contrib/propagators/envcar"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// execTemplate expands the placeholders otel-cli understands in the
+// command's arguments and --env values:
+//
+//	{{traceparent}}         the exec span's W3C traceparent
+//	{{trace_id}}            its trace id in hex
+//	{{span_id}}             its span id in hex
+//	{{trace_flags}}         its trace flags in hex, e.g. 01
+//	{{tracestate}}          the TRACESTATE otel-cli was given
+//	{{baggage}}             the BAGGAGE otel-cli was given
+//	{{baggage:KEY}}         the value of one baggage member, decoded
+//	{{header:NAME}}         "NAME: value" for traceparent, tracestate, or baggage
+//
+// Whitespace inside the braces is ignored. Anything else in double braces,
+// e.g. a docker --format string, is left alone, and \{{ is a literal {{ for
+// when a placeholder shouldn't be expanded.
+type execTemplate struct {
+	tp         traceparent.Traceparent
+	tracestate string
+	baggage    string
+}
+
+// newExecTemplate returns an execTemplate for the traceparent, picking up
+// tracestate and baggage from the environment unless --tp-ignore-env is set.
+func newExecTemplate(config Config, tp traceparent.Traceparent) execTemplate {
+	t := execTemplate{tp: tp}
+	if !config.TraceparentIgnoreEnv {
+		carrier := envcar.Carrier{}
+		t.tracestate = carrier.Get("tracestate")
+		t.baggage = carrier.Get("baggage")
+	}
+	return t
+}
+
+// expand returns s with the placeholders replaced.
+func (t execTemplate) expand(s string) string {
+	return expandPlaceholders(s, t.lookup)
+}
+
+// expandPlaceholders replaces each {{name}} in s that lookup knows with its
+// value, leaving the rest alone, and turns \{{ into a literal {{.
+func expandPlaceholders(s string, lookup func(string) (string, bool)) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			out.WriteString(s)
+			return out.String()
+		}
+
+		if start > 0 && s[start-1] == '\\' {
+			out.WriteString(s[:start-1])
+			out.WriteString("{{")
+			s = s[start+2:]
+			continue
+		}
+
+		length := strings.Index(s[start+2:], "}}")
+		if length < 0 {
+			out.WriteString(s)
+			return out.String()
+		}
+		end := start + 2 + length + 2
+
+		if value, ok := lookup(strings.TrimSpace(s[start+2 : end-2])); ok {
+			out.WriteString(s[:start])
+			out.WriteString(value)
+		} else {
+			out.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
+
+// lookup returns the value of a placeholder and whether it's one otel-cli
+// knows about.
+func (t execTemplate) lookup(name string) (string, bool) {
+	name, arg, hasArg := strings.Cut(name, ":")
+	name = strings.TrimSpace(name)
+	arg = strings.TrimSpace(arg)
+
+	switch {
+	case name == "traceparent" && !hasArg:
+		return t.tp.Encode(), true
+	case name == "trace_id" && !hasArg:
+		return t.tp.TraceIdString(), true
+	case name == "span_id" && !hasArg:
+		return t.tp.SpanIdString(), true
+	case name == "trace_flags" && !hasArg:
+		if t.tp.Sampling {
+			return "01", true
+		}
+		return "00", true
+	case name == "tracestate" && !hasArg:
+		return t.tracestate, true
+	case name == "baggage" && !hasArg:
+		return t.baggage, true
+	case name == "baggage":
+		// a BAGGAGE that doesn't parse has no members to look up
+		bag, _ := baggage.Parse(t.baggage)
+		return bag.Member(arg).Value(), true
+	case name == "header" && (arg == "traceparent" || arg == "tracestate" || arg == "baggage"):
+		value, _ := t.lookup(arg)
+		return arg + ": " + value, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,44 @@
+package otelcli
+
+import (
+	"testing"
+
+	"github.com/tobert/otel-cli/w3c/traceparent"
+)
+
+func TestExecTemplateExpand(t *testing.T) {
+	tp, err := traceparent.Parse("00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := execTemplate{
+		tp:         tp,
+		tracestate: "vendor=abc",
+		baggage:    "team=obs%20cli,env=ci",
+	}
+
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"{{traceparent}}", "00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01"},
+		{"id={{trace_id}}/{{ span_id }}/{{trace_flags}}", "id=e39280f2980af3a8600ae98c74f2dabf/023eee2731392b4d/01"},
+		{"{{tracestate}}", "vendor=abc"},
+		{"{{baggage}}", "team=obs%20cli,env=ci"},
+		{"{{baggage:team}} {{baggage:missing}}!", "obs cli !"},
+		{"{{header:traceparent}}", "traceparent: 00-e39280f2980af3a8600ae98c74f2dabf-023eee2731392b4d-01"},
+		{"{{header:tracestate}}", "tracestate: vendor=abc"},
+		// escaped and unknown placeholders are left alone
+		{`\{{trace_id}}`, "{{trace_id}}"},
+		{`\{{trace_id}}={{trace_id}}`, "{{trace_id}}=e39280f2980af3a8600ae98c74f2dabf"},
+		{"{{.State.Status}}", "{{.State.Status}}"},
+		{"{{header:span_id}}", "{{header:span_id}}"},
+		{"{{trace_id:x}}", "{{trace_id:x}}"},
+		{"unterminated {{span_id", "unterminated {{span_id"},
+	} {
+		if got := template.expand(tc.in); got != tc.want {
+			t.Errorf("expand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package otelcli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	"github.com/tobert/otel-cli/w3c/traceparent"
+	"go.opentelemetry.io/contrib/propagators/envcar"
+)
+
+// spanOutput is what otel-cli prints about the span it made or worked on
+// with --output json or shell, so scripts don't have to pick apart the
+// comments --tp-print writes.
+type spanOutput struct {
+	TraceId     string               `json:"trace_id"`
+	SpanId      string               `json:"span_id"`
+	Parent      string               `json:"parent"`
+	Traceparent string               `json:"traceparent"`
+	Tracestate  string               `json:"tracestate"`
+	Start       string               `json:"start"`
+	End         string               `json:"end"`
+	DurationMs  float64              `json:"duration_ms"`
+	ExitCode    int                  `json:"exit_code"`
+	Errors      otlpclient.ErrorList `json:"errors"`
+	tp          traceparent.Traceparent
+}
+
+// newSpanOutput fills in a spanOutput for the traceparent, parent span id,
+// and start/end times in Unix nanoseconds, which are left empty when zero,
+// along with the exit code so far and the errors saved in ctx.
+func newSpanOutput(ctx context.Context, config Config, tp traceparent.Traceparent, parent []byte, start, end uint64) spanOutput {
+	out := spanOutput{
+		TraceId:     tp.TraceIdString(),
+		SpanId:      tp.SpanIdString(),
+		Parent:      hex.EncodeToString(parent),
+		Traceparent: tp.Encode(),
+		Start:       formatUnixNano(start),
+		End:         formatUnixNano(end),
+		ExitCode:    Diag.ExecExitCode,
+		Errors:      otlpclient.GetErrorList(ctx),
+		tp:          tp,
+	}
+	if !config.TraceparentIgnoreEnv {
+		carrier := envcar.Carrier{}
+		out.Tracestate = carrier.Get("tracestate")
+	}
+	if start > 0 && end >= start {
+		out.DurationMs = float64(end-start) / float64(time.Millisecond)
+	}
+	return out
+}
+
+// formatUnixNano formats Unix nanoseconds as RFC3339, or "" for zero.
+func formatUnixNano(ts uint64) string {
+	if ts == 0 {
+		return ""
+	}
+	return time.Unix(0, int64(ts)).UTC().Format(time.RFC3339Nano)
+}
+
+// printSpanOutput writes out to target in the format chosen with --output.
+// Text is the original --tp-print output and is only written with
+// --tp-print, json and shell are always written. When errors were saved,
+// it SoftFails once they're printed, which failExport left for it to do.
+func (c Config) printSpanOutput(out spanOutput, target io.Writer) {
+	switch c.Output {
+	case "json":
+		js, err := json.Marshal(out)
+		c.SoftFailIfErr(err)
+		fmt.Fprintln(target, string(js))
+	case "shell":
+		exported := ""
+		if c.TraceparentPrintExport {
+			exported = "export "
+		}
+		errs := make([]string, len(out.Errors))
+		for i, e := range out.Errors {
+			errs[i] = e.Error
+		}
+		fmt.Fprintf(target, "OTEL_CLI_TRACE_ID=%s\nOTEL_CLI_SPAN_ID=%s\nOTEL_CLI_PARENT_SPAN_ID=%s\n%sTRACEPARENT=%s\n",
+			out.TraceId, out.SpanId, out.Parent, exported, out.Traceparent)
+		if out.Tracestate != "" {
+			fmt.Fprintf(target, "%sTRACESTATE=%s\n", exported, shellQuote(out.Tracestate))
+		}
+		fmt.Fprintf(target, "OTEL_CLI_SPAN_START=%s\nOTEL_CLI_SPAN_END=%s\nOTEL_CLI_DURATION_MS=%s\nOTEL_CLI_EXIT_CODE=%d\nOTEL_CLI_ERRORS=%s\n",
+			out.Start, out.End, strconv.FormatFloat(out.DurationMs, 'f', -1, 64), out.ExitCode, shellQuote(strings.Join(errs, "\n")))
+	default:
+		if c.TraceparentPrint {
+			out.tp.Fprint(target, c.TraceparentPrintExport)
+		}
+		return
+	}
+
+	if len(out.Errors) > 0 {
+		c.SoftFail("%s", out.Errors[len(out.Errors)-1].Error)
+	}
+}
+
+// failExport is SoftFail for errors sending telemetry. With --output json
+// or shell it only logs, so the error ends up in the output before otel-cli
+// exits.
+func (c Config) failExport(format string, a ...interface{}) {
+	if c.Output == "json" || c.Output == "shell" {
+		c.SoftLog(format, a...)
+		return
+	}
+	c.SoftFail(format, a...)
+}
+
+// failExportIfErr calls failExport only if err != nil.
+func (c Config) failExportIfErr(err error) {
+	if err != nil {
+		c.failExport("%s", err.Error())
+	}
+}
@@ -0,0 +1,66 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tobert/otel-cli/w3c/traceparent"
+)
+
+func TestPrintSpanOutput(t *testing.T) {
+	t.Setenv("TRACESTATE", "vendor=it's")
+	tp, err := traceparent.Parse("00-3433d5ae39bdfee397f44be5146867b3-8a5518f1e5c54d0a-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, _ := hex.DecodeString("beefcafefacedead")
+	start := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	out := newSpanOutput(context.Background(), DefaultConfig(), tp, parent,
+		uint64(start.UnixNano()), uint64(start.Add(1250*time.Microsecond).UnixNano()))
+
+	for _, tc := range []struct {
+		config Config
+		want   string
+	}{
+		{
+			config: DefaultConfig(),
+			want:   "",
+		},
+		{
+			config: DefaultConfig().WithTraceparentPrint(true),
+			want: "# trace id: 3433d5ae39bdfee397f44be5146867b3\n" +
+				"#  span id: 8a5518f1e5c54d0a\n" +
+				"TRACEPARENT=00-3433d5ae39bdfee397f44be5146867b3-8a5518f1e5c54d0a-01\n",
+		},
+		{
+			config: DefaultConfig().WithOutput("json"),
+			want: `{"trace_id":"3433d5ae39bdfee397f44be5146867b3","span_id":"8a5518f1e5c54d0a",` +
+				`"parent":"beefcafefacedead","traceparent":"00-3433d5ae39bdfee397f44be5146867b3-8a5518f1e5c54d0a-01",` +
+				`"tracestate":"vendor=it's","start":"2023-11-14T22:13:20Z","end":"2023-11-14T22:13:20.00125Z",` +
+				`"duration_ms":1.25,"exit_code":0,"errors":[]}` + "\n",
+		},
+		{
+			config: DefaultConfig().WithOutput("shell").WithTraceparentPrintExport(true),
+			want: "OTEL_CLI_TRACE_ID=3433d5ae39bdfee397f44be5146867b3\n" +
+				"OTEL_CLI_SPAN_ID=8a5518f1e5c54d0a\n" +
+				"OTEL_CLI_PARENT_SPAN_ID=beefcafefacedead\n" +
+				"export TRACEPARENT=00-3433d5ae39bdfee397f44be5146867b3-8a5518f1e5c54d0a-01\n" +
+				"export TRACESTATE='vendor=it'\\''s'\n" +
+				"OTEL_CLI_SPAN_START=2023-11-14T22:13:20Z\n" +
+				"OTEL_CLI_SPAN_END=2023-11-14T22:13:20.00125Z\n" +
+				"OTEL_CLI_DURATION_MS=1.25\n" +
+				"OTEL_CLI_EXIT_CODE=0\n" +
+				"OTEL_CLI_ERRORS=''\n",
+		},
+	} {
+		buf := new(bytes.Buffer)
+		tc.config.printSpanOutput(out, buf)
+		if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+			t.Errorf("output %q did not match (-want +got):\n%s", tc.config.Output, diff)
+		}
+	}
+}
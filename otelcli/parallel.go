@@ -0,0 +1,360 @@
+package otelcli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// parallelMaxExitCode caps otel-cli parallel's exit code, which is the
+// number of failed jobs, same as GNU parallel.
+const parallelMaxExitCode = 101
+
+// shellSafe matches strings that don't need quoting for a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// parallelCmd sets up the `otel-cli parallel` command
+func parallelCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "parallel",
+		Short: "run commands in parallel, each in a child span of one parent span",
+		Long: `Runs each argument as a shell command line, or each line of stdin when there
+are no arguments, up to --jobs at a time. Every job gets a child span of a
+parent span covering the whole run, with TRACEPARENT set to its own span.
+All of the spans are sent together once the last job is done.
+
+With --command, the arguments or lines are inputs instead, and each job runs
+the command with {} replaced by an input, quoted for the shell. When there's
+no {} the input is added to the end.
+
+otel-cli parallel exits with the number of jobs that failed, up to 101.
+
+Examples:
+
+otel-cli parallel --name "lint" -- "make lint-go" "make lint-docs"
+
+find . -name '*.log' | otel-cli parallel --jobs 4 --command 'gzip -9 {}'
+
+otel-cli parallel --fail-fast --command './deploy.sh {}' -- us-east eu-west
+`,
+		Run: doParallel,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().IntVarP(
+		&config.ParallelJobs,
+		"jobs",
+		"j",
+		defaults.ParallelJobs,
+		"how many jobs to run at once, default is the number of CPUs",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ParallelCommand,
+		"command",
+		defaults.ParallelCommand,
+		"a command line to run for each input, with {} replaced by the input",
+	)
+
+	cmd.Flags().BoolVar(
+		&config.ParallelFailFast,
+		"fail-fast",
+		defaults.ParallelFailFast,
+		"after the first failed job, start no more and stop the ones still running",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecCommandTimeout,
+		"command-timeout",
+		defaults.ExecCommandTimeout,
+		"timeout for each job, when 0 otel-cli will wait forever",
+	)
+
+	cmd.Flags().StringVar(
+		&config.ExecKillGrace,
+		"kill-grace",
+		defaults.ExecKillGrace,
+		"after --command-timeout or --fail-fast sends SIGTERM, how long to wait before sending SIGKILL",
+	)
+
+	return &cmd
+}
+
+func doParallel(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	span := config.NewProtobufSpan()
+
+	// ^C goes to the jobs too, so this only has to stop starting new ones
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, execStopSignals...)
+	defer signal.Stop(stop)
+
+	inputs := make(chan string)
+	go parallelInputs(config, args, os.Stdin, inputs)
+
+	pr := newParallelRunner(config, span)
+	pr.run(inputs, stop)
+
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+	span.Attributes = append(span.Attributes,
+		int64Attr("parallel.jobs", int64(pr.jobs)),
+		int64Attr("parallel.jobs.failed", int64(pr.failed)),
+		int64Attr("parallel.concurrency", int64(pr.limit)),
+	)
+	if pr.failed > 0 {
+		span.Status = &tracev1.Status{
+			Code:    tracev1.Status_STATUS_CODE_ERROR,
+			Message: fmt.Sprintf("%d of %d jobs failed", pr.failed, pr.jobs),
+		}
+	}
+	Diag.ExecExitCode = min(pr.failed, parallelMaxExitCode)
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+	ctx, client := StartClient(ctx, config)
+	ctx, err := otlpclient.SendSpans(ctx, client, config, append(pr.spans, span))
+	if err != nil {
+		config.failExport("unable to send span: %s", err)
+	}
+	ctx, err = client.Stop(ctx)
+	if err != nil {
+		config.failExport("client.Stop() failed: %s", err)
+	}
+
+	config.PropagateTraceparent(ctx, span, os.Stdout)
+}
+
+// parallelInputs sends the args, or the non-blank lines of stdin when there
+// are none, to inputs and closes it.
+func parallelInputs(config Config, args []string, stdin io.Reader, inputs chan<- string) {
+	defer close(inputs)
+
+	if len(args) > 0 {
+		for _, arg := range args {
+			inputs <- arg
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			inputs <- line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		config.SoftLog("otel-cli parallel: error reading stdin: %s", err)
+	}
+}
+
+// parallelRunner runs jobs as child spans of a parent span.
+type parallelRunner struct {
+	config Config
+	parent *tracev1.Span
+	limit  int
+	cancel chan struct{} // closed by --fail-fast to stop running jobs
+
+	mu         sync.Mutex // guards everything below, and parent.Events
+	spans      []*tracev1.Span
+	jobs       int
+	failed     int
+	failedFast bool
+}
+
+func newParallelRunner(config Config, parent *tracev1.Span) *parallelRunner {
+	limit := config.ParallelJobs
+	if limit <= 0 {
+		limit = runtime.NumCPU()
+	}
+
+	return &parallelRunner{
+		config: config,
+		parent: parent,
+		limit:  limit,
+		cancel: make(chan struct{}),
+	}
+}
+
+// run starts a job for each input, up to limit at a time, until inputs is
+// closed, a stop signal arrives, or --fail-fast kicks in, then waits for
+// the running jobs to finish.
+func (pr *parallelRunner) run(inputs <-chan string, stop <-chan os.Signal) {
+	slots := make(chan struct{}, pr.limit)
+	var wg sync.WaitGroup
+	var number int
+
+jobs:
+	for {
+		select {
+		case slots <- struct{}{}:
+		case sig := <-stop:
+			pr.stopped(sig)
+			break jobs
+		case <-pr.cancel:
+			break jobs
+		}
+
+		var input string
+		select {
+		case in, ok := <-inputs:
+			if !ok {
+				break jobs
+			}
+			input = in
+		case sig := <-stop:
+			pr.stopped(sig)
+			break jobs
+		case <-pr.cancel:
+			break jobs
+		}
+
+		// select picks at random when more than one case is ready, so make
+		// sure --fail-fast didn't kick in while waiting
+		select {
+		case <-pr.cancel:
+			break jobs
+		default:
+		}
+
+		number++
+		wg.Add(1)
+		go func(number int) {
+			defer wg.Done()
+			pr.job(number, input)
+			<-slots
+		}(number)
+	}
+
+	wg.Wait()
+}
+
+// stopped records that a signal stopped new jobs from starting.
+func (pr *parallelRunner) stopped(sig os.Signal) {
+	event := otlpclient.NewProtobufSpanEvent()
+	event.Name = "parallel.cancelled"
+	event.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{
+		"signal.name": execSignalName(sig),
+	})
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.parent.Events = append(pr.parent.Events, event)
+}
+
+// job runs one job in its own child span of the parent.
+func (pr *parallelRunner) job(number int, input string) {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = pr.parent.TraceId
+	span.ParentSpanId = pr.parent.SpanId
+	if pr.config.GetIsRecording() {
+		span.SpanId = otlpclient.GenerateSpanId()
+	}
+	span.Name = fmt.Sprintf("%s job %d", pr.parent.Name, number)
+	span.Kind = pr.parent.Kind
+	span.Attributes = append(span.Attributes, int64Attr("parallel.job.number", int64(number)))
+
+	line := input
+	if pr.config.ParallelCommand != "" {
+		line = parallelSubstitute(pr.config.ParallelCommand, input)
+		span.Attributes = append(span.Attributes, stringAttr("parallel.job.input", input))
+	}
+
+	childEnv := []string{}
+	execInjectTraceparent(pr.config, span, func(key, value string) {
+		childEnv = append(childEnv, key+"="+value)
+	})
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "TRACEPARENT=") && !strings.HasPrefix(env, "OTEL_CLI_FD=") && !strings.HasPrefix(env, "OTEL_CLI_FIFO=") {
+			childEnv = append(childEnv, env)
+		}
+	}
+
+	shell := parallelShell()
+	child := exec.Command(shell[0], append(shell[1:], line)...)
+	// jobs share otel-cli's output but not its input, which may be the job list
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = childEnv
+
+	span.StartTimeUnixNano = uint64(time.Now().UnixNano())
+	result := runChildUntil(pr.config, child, pr.cancel, false)
+	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+
+	span.Events = append(span.Events, result.events...)
+	span.Attributes = append(span.Attributes, processArgAttrs(child.Args)...)
+	if child.Process != nil {
+		span.Attributes = append(span.Attributes, processPidAttrs(pr.config, int64(child.Process.Pid), int64(os.Getpid()))...)
+	}
+	exitAttrs, status := execExitAttrs(pr.config, result, child.ProcessState)
+	span.Attributes = append(span.Attributes, exitAttrs...)
+	if status != nil {
+		span.Status = status
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.spans = append(pr.spans, span)
+	pr.jobs++
+	if status.GetCode() != tracev1.Status_STATUS_CODE_ERROR {
+		return
+	}
+	pr.failed++
+
+	if pr.config.ParallelFailFast && !pr.failedFast {
+		pr.failedFast = true
+		close(pr.cancel)
+
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = "parallel.fail_fast"
+		event.Attributes = append(event.Attributes, int64Attr("parallel.job.number", int64(number)))
+		pr.parent.Events = append(pr.parent.Events, event)
+	}
+}
+
+// parallelSubstitute replaces {} in the --command template with the input,
+// quoted for the shell, or adds it to the end when there's no {}.
+func parallelSubstitute(template, input string) string {
+	quoted := shellQuote(input)
+	if !strings.Contains(template, "{}") {
+		return template + " " + quoted
+	}
+	return strings.ReplaceAll(template, "{}", quoted)
+}
+
+// shellQuote single-quotes s for a POSIX shell unless it's safe as-is.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parallelShell returns the command and arguments that run a job's
+// command line.
+func parallelShell() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C"}
+	}
+	return []string{"/bin/sh", "-c"}
+}
@@ -0,0 +1,18 @@
+package otelcli
+
+import "testing"
+
+func TestParallelSubstitute(t *testing.T) {
+	for _, tc := range []struct {
+		template, input, want string
+	}{
+		{"gzip -9 {}", "app.log", "gzip -9 app.log"},
+		{"cp {} {}.bak", "my file", "cp 'my file' 'my file'.bak"},
+		{"echo", "it's $HOME", `echo 'it'\''s $HOME'`},
+		{"echo {}", "", "echo ''"},
+	} {
+		if got := parallelSubstitute(tc.template, tc.input); got != tc.want {
+			t.Errorf("%q with %q: expected %q but got %q", tc.template, tc.input, tc.want, got)
+		}
+	}
+}
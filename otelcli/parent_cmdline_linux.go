@@ -0,0 +1,22 @@
+package otelcli
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// parentCommandLine returns the command line of otel-cli's parent process
+// from /proc, e.g. the script that ran it, or nil if it can't be read.
+func parentCommandLine() []string {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(os.Getppid()) + "/cmdline")
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	args := []string{}
+	for _, arg := range bytes.Split(bytes.TrimSuffix(data, []byte{0}), []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args
+}
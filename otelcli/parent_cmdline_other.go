@@ -0,0 +1,9 @@
+//go:build !linux
+
+package otelcli
+
+// parentCommandLine returns nil, the parent's command line only comes from
+// /proc on Linux for now.
+func parentCommandLine() []string {
+	return nil
+}
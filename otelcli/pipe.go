@@ -0,0 +1,366 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// pipeMaxLineBytes is the longest line otel-cli pipe will turn into an event,
+// anything past this is still copied to stdout but left off the event.
+const pipeMaxLineBytes = 64 * 1024
+
+// pipeCmd sets up the `otel-cli pipe` command
+func pipeCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "pipe",
+		Short: "copy stdin to stdout, turning lines into span events and child spans",
+		Long: `Copies stdin to stdout, sending a span covering the life of the stream when
+it ends. Each line becomes an event on the span, or just the lines matching --match.
+
+--span-start and --span-end take pairs of regular expressions, matched up by
+position, and lines between a start and an end match become a child span with
+the events for those lines. Spans can nest. Named capture groups become
+attributes on the events and spans, and a group called "name" sets the
+event or span name.
+
+Examples:
+
+make 2>&1 | otel-cli pipe --name "make" \
+	--span-start 'Entering directory .(?P<name>[^'"'"']+)' \
+	--span-end 'Leaving directory .(?P<name>[^'"'"']+)'
+
+./long-job | otel-cli pipe --match '^progress: (?P<percent>\d+)%'
+`,
+		Run:  doPipe,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(
+		&config.PipeMatch,
+		"match",
+		defaults.PipeMatch,
+		"a regular expression, only matching lines become events, default is every line",
+	)
+
+	cmd.Flags().StringVar(
+		&config.PipeEventName,
+		"event-name",
+		defaults.PipeEventName,
+		"the name of line events, when the regular expression doesn't capture a name",
+	)
+
+	cmd.Flags().StringArrayVar(
+		&config.PipeSpanStart,
+		"span-start",
+		defaults.PipeSpanStart,
+		"a regular expression for lines that start a child span, can be repeated",
+	)
+
+	cmd.Flags().StringArrayVar(
+		&config.PipeSpanEnd,
+		"span-end",
+		defaults.PipeSpanEnd,
+		"a regular expression for lines that end the span started by the --span-start in the same position",
+	)
+
+	cmd.Flags().IntVar(
+		&config.PipeMaxEvents,
+		"max-events",
+		defaults.PipeMaxEvents,
+		"the most line events to record across all spans, the rest are counted as dropped",
+	)
+
+	return &cmd
+}
+
+func doPipe(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	span := config.NewProtobufSpan()
+
+	pt, err := newPipeTracer(config, span)
+	config.SoftFailIfErr(err)
+
+	// when the reader downstream goes away, keep reading so the writer
+	// upstream doesn't get a SIGPIPE, and still send the span
+	signal.Ignore(syscall.SIGPIPE)
+
+	// ^C usually goes to the whole pipeline, so wrap up and send what there is
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, execStopSignals...)
+	defer signal.Stop(stop)
+
+	pr := pipeReader{lines: make(chan pipeLine, 100)}
+	go pr.copy(os.Stdin, os.Stdout)
+
+read:
+	for {
+		select {
+		case line, ok := <-pr.lines:
+			if !ok {
+				if pr.err != nil {
+					config.SoftLog("otel-cli pipe: %s", pr.err)
+				}
+				break read
+			}
+			pt.line(line.text, line.ts)
+		case <-stop:
+			break read
+		}
+	}
+
+	span.Attributes = append(span.Attributes, int64Attr("pipe.bytes", pr.bytes.Load()))
+	spans := pt.finish(time.Now())
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+	ctx, client := StartClient(ctx, config)
+	ctx, err = otlpclient.SendSpans(ctx, client, config, spans)
+	config.failExportIfErr(err)
+	ctx, err = client.Stop(ctx)
+	config.failExportIfErr(err)
+
+	// stdout belongs to the stream, so the traceparent goes to stderr
+	config.PropagateTraceparent(ctx, span, os.Stderr)
+}
+
+// pipeLine is a line of input and when it was read.
+type pipeLine struct {
+	text string
+	ts   time.Time
+}
+
+// pipeReader copies input to output as it arrives, so partial lines like
+// progress bars show up immediately, and sends complete lines to be traced.
+type pipeReader struct {
+	lines chan pipeLine
+	bytes atomic.Int64
+	err   error // only safe to read after lines is closed
+}
+
+// copy reads until EOF or an error, then closes pr.lines. Errors writing
+// to out stop the copying but not the reading.
+func (pr *pipeReader) copy(in io.Reader, out io.Writer) {
+	defer close(pr.lines)
+
+	buf := make([]byte, 32*1024)
+	var partial []byte
+	var writeErr error
+
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			pr.bytes.Add(int64(n))
+			if writeErr == nil {
+				_, writeErr = out.Write(buf[:n])
+			}
+
+			data := buf[:n]
+			for len(data) > 0 {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					partial = appendLimited(partial, data)
+					break
+				}
+				partial = appendLimited(partial, data[:i])
+				pr.lines <- pipeLine{text: string(bytes.TrimSuffix(partial, []byte{'\r'})), ts: time.Now()}
+				partial = partial[:0]
+				data = data[i+1:]
+			}
+		}
+
+		if err != nil {
+			if len(partial) > 0 {
+				pr.lines <- pipeLine{text: string(bytes.TrimSuffix(partial, []byte{'\r'})), ts: time.Now()}
+			}
+			if !errors.Is(err, io.EOF) {
+				pr.err = fmt.Errorf("error reading stdin: %w", err)
+			} else if writeErr != nil {
+				pr.err = fmt.Errorf("error writing stdout: %w", writeErr)
+			}
+			return
+		}
+	}
+}
+
+// appendLimited appends data to line up to pipeMaxLineBytes.
+func appendLimited(line, data []byte) []byte {
+	if room := pipeMaxLineBytes - len(line); room < len(data) {
+		data = data[:max(room, 0)]
+	}
+	return append(line, data...)
+}
+
+// pipeSpanPair is a --span-start and its --span-end.
+type pipeSpanPair struct {
+	start *regexp.Regexp
+	end   *regexp.Regexp
+}
+
+// pipeTracer turns lines of text into events and child spans of root.
+type pipeTracer struct {
+	config Config
+	match  *regexp.Regexp
+	pairs  []pipeSpanPair
+	root   *tracev1.Span
+	spans  *spanStack // tagged with the index of the pair that started them
+	lines  int64
+	events int
+}
+
+// newPipeTracer compiles the regular expressions from config and returns
+// a tracer ready for lines.
+func newPipeTracer(config Config, root *tracev1.Span) (*pipeTracer, error) {
+	pt := pipeTracer{config: config, root: root, spans: newSpanStack(config, root)}
+
+	var err error
+	if config.PipeMatch != "" {
+		if pt.match, err = regexp.Compile(config.PipeMatch); err != nil {
+			return nil, fmt.Errorf("invalid --match regular expression: %w", err)
+		}
+	}
+
+	if len(config.PipeSpanStart) != len(config.PipeSpanEnd) {
+		return nil, fmt.Errorf("every --span-start needs a --span-end, got %d and %d", len(config.PipeSpanStart), len(config.PipeSpanEnd))
+	}
+	for i := range config.PipeSpanStart {
+		var pair pipeSpanPair
+		if pair.start, err = regexp.Compile(config.PipeSpanStart[i]); err != nil {
+			return nil, fmt.Errorf("invalid --span-start regular expression: %w", err)
+		}
+		if pair.end, err = regexp.Compile(config.PipeSpanEnd[i]); err != nil {
+			return nil, fmt.Errorf("invalid --span-end regular expression: %w", err)
+		}
+		pt.pairs = append(pt.pairs, pair)
+	}
+
+	return &pt, nil
+}
+
+// line processes one line of input. Span ends are checked before starts so
+// a single line can end one span and start the next. The line becomes an
+// event on the span it started, the span it ended, or the innermost open
+// span, in that order.
+func (pt *pipeTracer) line(text string, ts time.Time) {
+	pt.lines++
+
+	var ended *tracev1.Span
+	for i, pair := range pt.pairs {
+		if m := pair.end.FindStringSubmatch(text); m != nil {
+			if span := pt.end(i, pipeCaptures(pair.end, m), ts); span != nil {
+				ended = span
+			}
+		}
+	}
+
+	target := ended
+	for i, pair := range pt.pairs {
+		if m := pair.start.FindStringSubmatch(text); m != nil {
+			target = pt.start(i, m[0], pipeCaptures(pair.start, m), ts)
+		}
+	}
+	if target == nil {
+		target = pt.spans.current()
+	}
+
+	captures := map[string]string{}
+	if pt.match != nil {
+		m := pt.match.FindStringSubmatch(text)
+		if m == nil {
+			return
+		}
+		captures = pipeCaptures(pt.match, m)
+	}
+
+	if pt.events >= pt.config.PipeMaxEvents {
+		target.DroppedEventsCount++
+		return
+	}
+	pt.events++
+
+	event := otlpclient.NewProtobufSpanEvent()
+	event.TimeUnixNano = uint64(ts.UnixNano())
+	event.Name = pt.config.PipeEventName
+	if name, ok := captures["name"]; ok {
+		event.Name = name
+		delete(captures, "name")
+	}
+	event.Attributes = append(otlpclient.StringMapAttrsToProtobuf(captures),
+		stringAttr("pipe.line", text),
+		int64Attr("pipe.line_number", pt.lines),
+	)
+	target.Events = append(target.Events, event)
+}
+
+// start opens a child span of the current span, named by the "name" capture
+// or the matching text, and returns it.
+func (pt *pipeTracer) start(pair int, matched string, captures map[string]string, ts time.Time) *tracev1.Span {
+	name := matched
+	if captured, ok := captures["name"]; ok {
+		name = captured
+		delete(captures, "name")
+	}
+
+	span := pt.spans.start(name, pair, ts)
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(captures)
+	return span
+}
+
+// end closes the innermost open span started by the same pair, and when the
+// end expression captures a name, with that name. Spans opened inside it
+// are closed along with it. Returns the span, or nil if nothing matched.
+func (pt *pipeTracer) end(pair int, captures map[string]string, ts time.Time) *tracev1.Span {
+	name, hasName := captures["name"]
+	delete(captures, "name")
+
+	span := pt.spans.end(func(open stackedSpan) bool {
+		return open.tag == pair && (!hasName || open.span.Name == name)
+	}, ts)
+	if span != nil {
+		span.Attributes = append(span.Attributes, otlpclient.StringMapAttrsToProtobuf(captures)...)
+	}
+	return span
+}
+
+// finish ends any spans that are still open along with the root span, and
+// returns them all with the root span last.
+func (pt *pipeTracer) finish(ts time.Time) []*tracev1.Span {
+	spans := pt.spans.finish("pipe", ts)
+
+	pt.root.EndTimeUnixNano = uint64(ts.UnixNano())
+	pt.root.Attributes = append(pt.root.Attributes, int64Attr("pipe.lines", pt.lines))
+
+	return append(spans, pt.root)
+}
+
+// pipeCaptures returns the named groups in a match that matched something.
+func pipeCaptures(re *regexp.Regexp, match []string) map[string]string {
+	out := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name != "" && match[i] != "" {
+			out[name] = match[i]
+		}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package otelcli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestPipeTracer(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	// the same expression for start and end means each match ends the
+	// previous step and starts the next one
+	config.PipeSpanStart = []string{`^== step (?P<name>\w+)`, `^begin (?P<name>\w+)`}
+	config.PipeSpanEnd = []string{`^== (step \w+|done)`, `^end (?P<name>\w+) (?P<status>\w+)`}
+	config.PipeMaxEvents = 7
+
+	root := config.NewProtobufSpan()
+	pt, err := newPipeTracer(config, root)
+	if err != nil {
+		t.Fatalf("newPipeTracer failed: %s", err)
+	}
+
+	ts := time.Now()
+	for _, line := range []string{
+		"hello",
+		"== step fetch",
+		"begin download",
+		"downloading",
+		"end download ok",
+		"== step build",
+		"begin compile",
+		"compiling",
+		"== done",
+		"goodbye",
+	} {
+		ts = ts.Add(time.Second)
+		pt.line(line, ts)
+	}
+	spans := pt.finish(ts.Add(time.Second))
+
+	type result struct {
+		Name, Parent string
+		Attrs        map[string]string
+		Events       []string
+		Dropped      uint32
+	}
+	names := map[string]string{}
+	for _, span := range spans {
+		names[string(span.SpanId)] = span.Name
+	}
+	got := []result{}
+	for _, span := range spans {
+		r := result{
+			Name:    span.Name,
+			Parent:  names[string(span.ParentSpanId)],
+			Attrs:   otlpclient.SpanAttributesToStringMap(span),
+			Dropped: span.DroppedEventsCount,
+		}
+		for _, e := range span.Events {
+			attrs := otlpclient.SpanAttributesToStringMap(&tracev1.Span{Attributes: e.Attributes})
+			r.Events = append(r.Events, attrs["pipe.line_number"]+":"+attrs["pipe.line"])
+		}
+		if span.EndTimeUnixNano <= span.StartTimeUnixNano {
+			t.Errorf("span %q ends before it starts", span.Name)
+		}
+		got = append(got, r)
+	}
+
+	expect := []result{
+		{Name: "download", Parent: "fetch", Attrs: map[string]string{"status": "ok"}, Events: []string{"3:begin download", "4:downloading", "5:end download ok"}},
+		{Name: "fetch", Parent: root.Name, Attrs: map[string]string{}, Events: []string{"2:== step fetch"}},
+		{Name: "compile", Parent: "build", Attrs: map[string]string{}, Events: []string{"7:begin compile"}, Dropped: 1},
+		{Name: "build", Parent: root.Name, Attrs: map[string]string{}, Events: []string{"6:== step build"}, Dropped: 1},
+		{Name: root.Name, Attrs: map[string]string{"pipe.lines": "10"}, Events: []string{"1:hello"}, Dropped: 1},
+	}
+
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("pipe spans didn't match (-want +got):\n%s", diff)
+	}
+}
+
+func TestPipeTracerUnterminated(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	config.PipeSpanStart = []string{`^start`}
+	config.PipeSpanEnd = []string{`^stop`}
+
+	pt, err := newPipeTracer(config, config.NewProtobufSpan())
+	if err != nil {
+		t.Fatalf("newPipeTracer failed: %s", err)
+	}
+	pt.line("start", time.Now())
+	spans := pt.finish(time.Now())
+
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans but got %d", len(spans))
+	}
+	if otlpclient.SpanAttributesToStringMap(spans[0])["pipe.span.unterminated"] != "true" {
+		t.Error("expected the open span to be marked unterminated")
+	}
+}
+
+func TestNewPipeTracerErrors(t *testing.T) {
+	config := DefaultConfig()
+	config.PipeSpanStart = []string{"a"}
+	if _, err := newPipeTracer(config, config.NewProtobufSpan()); err == nil {
+		t.Error("expected an error for a --span-start without a --span-end")
+	}
+
+	config.PipeSpanEnd = []string{"("}
+	if _, err := newPipeTracer(config, config.NewProtobufSpan()); err == nil || !strings.Contains(err.Error(), "--span-end") {
+		t.Errorf("expected an error about --span-end but got %v", err)
+	}
+}
+
+func TestPipeReader(t *testing.T) {
+	pr := pipeReader{lines: make(chan pipeLine, 10)}
+	var out strings.Builder
+	pr.copy(strings.NewReader("one\r\ntwo\n\nthree"), &out)
+
+	got := []string{}
+	for line := range pr.lines {
+		got = append(got, line.text)
+	}
+	if diff := cmp.Diff([]string{"one", "two", "", "three"}, got); diff != "" {
+		t.Errorf("lines didn't match (-want +got):\n%s", diff)
+	}
+	if out.String() != "one\r\ntwo\n\nthree" || pr.bytes.Load() != 15 {
+		t.Errorf("expected input to be copied as-is but got %q (%d bytes)", out.String(), pr.bytes.Load())
+	}
+}
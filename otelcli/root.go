@@ -54,6 +54,9 @@ func createRootCmd(config *Config) *cobra.Command {
 				// will need to specify --fail --verbose flags to see these errors
 				config.SoftFail("Error while loading environment variables: %s", err)
 			}
+			if config.Output != "text" && config.Output != "json" && config.Output != "shell" {
+				config.SoftFail("invalid --output %q, must be text, json, or shell", config.Output)
+			}
 		},
 	}
 
@@ -61,6 +64,10 @@ func createRootCmd(config *Config) *cobra.Command {
 	rootCmd.Flags().SortFlags = false
 	rootCmd.Version = config.Version
 
+	// --output applies to everything that reports on a span, so it's global
+	defaults := DefaultConfig()
+	rootCmd.PersistentFlags().StringVar(&config.Output, "output", defaults.Output, "how to print span info: text (only with --tp-print), json, or shell")
+
 	Diag.NumArgs = len(os.Args) - 1
 	Diag.CliArgs = []string{}
 	if len(os.Args) > 1 {
@@ -70,6 +77,8 @@ func createRootCmd(config *Config) *cobra.Command {
 	// add all the subcommands to rootCmd
 	rootCmd.AddCommand(spanCmd(config))
 	rootCmd.AddCommand(execCmd(config))
+	rootCmd.AddCommand(pipeCmd(config))
+	rootCmd.AddCommand(parallelCmd(config))
 	rootCmd.AddCommand(statusCmd(config))
 	rootCmd.AddCommand(serverCmd(config))
 	rootCmd.AddCommand(versionCmd(config))
@@ -147,6 +156,7 @@ func addSpanParams(cmd *cobra.Command, config *Config) {
 
 	// --name / -s
 	cmd.Flags().StringVarP(&config.SpanName, "name", "n", defaults.SpanName, "set the name of the span")
+	cmd.Flags().StringVar(&config.NameTemplate, "name-template", defaults.NameTemplate, "generate span names from a template when --name isn't set, e.g. '{{command}} in {{dir}}'")
 	// --service / -n
 	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the traces")
 	// --kind / -k
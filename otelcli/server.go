@@ -1,6 +1,7 @@
 package otelcli
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -8,7 +9,9 @@ import (
 )
 
 const defaultOtlpEndpoint = "grpc://localhost:4317"
+const defaultZipkinEndpoint = "localhost:9411"
 const spanBgSockfilename = "otel-cli-background.sock"
+const spanBgSockPrefix = "otel-cli-background-" // + --bg-name + .sock
 
 func serverCmd(config *Config) *cobra.Command {
 	cmd := cobra.Command{
@@ -24,8 +27,9 @@ func serverCmd(config *Config) *cobra.Command {
 }
 
 // runServer runs the server on either grpc or http and blocks until the server
-// stops or is killed.
-func runServer(config Config, cb otlpserver.Callback, stop otlpserver.Stopper) {
+// stops or is killed. Logs and metrics callbacks may be nil, in which case those
+// signals are accepted and dropped.
+func runServer(config Config, cb otlpserver.Callback, stop otlpserver.Stopper, logsCb otlpserver.LogsCallback, metricsCb otlpserver.MetricsCallback) {
 	// unlike the rest of otel-cli, server should default to localhost:4317
 	if config.Endpoint == "" {
 		config.Endpoint = defaultOtlpEndpoint
@@ -42,7 +46,53 @@ func runServer(config Config, cb otlpserver.Callback, stop otlpserver.Stopper) {
 	} else {
 		cs = otlpserver.NewServer("grpc", cb, stop)
 	}
+	cs.SetLogsCallback(logsCb)
+	cs.SetMetricsCallback(metricsCb)
 
-	defer cs.Stop()
-	cs.ListenAndServe(endpointURL.Host)
+	// all servers share one pipeline so callbacks see one ordered stream
+	pipeline := otlpserver.NewPipeline(otlpserver.DefaultPipelineDepth)
+	cs.SetPipeline(pipeline)
+
+	// zipkin always comes in over HTTP so it gets its own server on its own port
+	if config.ServerZipkinEndpoint != "" {
+		zs := otlpserver.NewServer("http", cb, stop)
+		zs.SetLogsCallback(logsCb)
+		zs.SetMetricsCallback(metricsCb)
+		zs.SetPipeline(pipeline)
+		defer zs.Stop()
+		go func() {
+			zs.ListenAndServe(parseZipkinEndpoint(config.ServerZipkinEndpoint))
+			// e.g. --max-spans was reached on the zipkin side, stop everything
+			cs.Stop()
+		}()
+	}
+
+	listenAddr := endpointURL.Host
+	if endpointURL.Scheme == "unix" {
+		listenAddr = "unix://" + endpointURL.Path
+	}
+
+	cs.ListenAndServe(listenAddr)
+	// make sure everything received has been through the callbacks before
+	// returning, callers flush their output after this
+	cs.StopWait()
+}
+
+// addServerParams adds the flags shared by all the server subcommands.
+func addServerParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+
+	// --zipkin-endpoint accepts Zipkin v2 JSON at /api/v2/spans alongside OTLP
+	cmd.Flags().StringVar(&config.ServerZipkinEndpoint, "zipkin-endpoint", defaults.ServerZipkinEndpoint, "also accept Zipkin v2 JSON spans over HTTP on this host:port, e.g. "+defaultZipkinEndpoint)
+}
+
+// parseZipkinEndpoint takes host:port or an http:// URL and returns host:port
+// for the listener.
+func parseZipkinEndpoint(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		if u, err := url.Parse(endpoint); err == nil {
+			return u.Host
+		}
+	}
+	return endpoint
 }
@@ -1,26 +1,73 @@
 package otelcli
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tobert/otel-cli/otlpclient"
 	"github.com/tobert/otel-cli/otlpserver"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
-// jsonSvr holds the command-line configured settings for otel-cli server json
+// jsonSvr holds the command-line configured settings for otel-cli server json.
+// The counters are only touched by the callbacks, which otlpserver runs one
+// at a time, but traces is also read by the idle timer so it has a lock.
+// traces is nil unless --trace-idle needs spans held until a trace is idle.
 var jsonSvr struct {
-	outDir    string
-	stdout    bool
-	maxSpans  int
-	spansSeen int
+	outDir      string
+	stdout      bool
+	maxSpans    int
+	spansSeen   int
+	traceIdle   string
+	logsSeen    int
+	metricsSeen int
+	traces      map[string]*jsonTrace // in-flight traces by trace id, guarded by mu
+	mu          sync.Mutex
+}
+
+// jsonTrace accumulates the spans of one trace so server json can write an
+// assembled trace.json after the trace goes idle.
+type jsonTrace struct {
+	TraceId  string          `json:"trace_id"`
+	Spans    []jsonTraceSpan `json:"spans"`
+	lastSeen time.Time
+}
+
+// jsonTraceSpan is a span along with the resource it arrived with.
+type jsonTraceSpan struct {
+	Span     *tracepb.Span        `json:"span"`
+	Resource *resourcepb.Resource `json:"resource"`
+}
+
+// jsonTraceIndex is written to tid/index.json and lists every span seen so far
+// for the trace without any of the bulky attribute or event data.
+type jsonTraceIndex struct {
+	TraceId string                `json:"trace_id"`
+	Spans   []jsonTraceIndexEntry `json:"spans"`
+}
+
+// jsonTraceIndexEntry is one span's summary in a jsonTraceIndex.
+type jsonTraceIndexEntry struct {
+	SpanId       string `json:"span_id"`
+	ParentSpanId string `json:"parent_span_id"`
+	Name         string `json:"name"`
+	ServiceName  string `json:"service_name"`
+	Start        uint64 `json:"start_time_unix_nano"`
+	End          uint64 `json:"end_time_unix_nano"`
+	DurationNs   int64  `json:"duration_ns"`
 }
 
 func serverJsonCmd(config *Config) *cobra.Command {
@@ -32,16 +79,27 @@ func serverJsonCmd(config *Config) *cobra.Command {
 	}
 
 	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
 	cmd.Flags().StringVar(&jsonSvr.outDir, "dir", "", "write spans to json in the specified directory")
 	cmd.Flags().BoolVar(&jsonSvr.stdout, "stdout", false, "write span jsons to stdout")
 	cmd.Flags().IntVar(&jsonSvr.maxSpans, "max-spans", 0, "exit the server after this many spans come in")
+	cmd.Flags().StringVar(&jsonSvr.traceIdle, "trace-idle", "", "write an assembled trace.json once a trace has received no spans for this long, e.g. 5s")
 
 	return &cmd
 }
 
 func doServerJson(cmd *cobra.Command, args []string) {
 	config := getConfig(cmd.Context())
-	stop := func(otlpserver.OtlpServer) {}
+
+	traceIdle, err := parseDuration(jsonSvr.traceIdle)
+	config.SoftFailIfErr(err)
+
+	// the idle loop is stopped by the server's stop callback or, for servers
+	// that don't call it, once runServer returns
+	idleDone := make(chan struct{})
+	var idleOnce sync.Once
+	stopIdle := func() { idleOnce.Do(func() { close(idleDone) }) }
+	stop := func(otlpserver.OtlpServer) { stopIdle() }
 	cs := otlpserver.NewGrpcServer(renderJson, stop)
 
 	// stops the grpc server after timeout
@@ -53,7 +111,35 @@ func doServerJson(cmd *cobra.Command, args []string) {
 		}()
 	}
 
-	runServer(config, renderJson, stop)
+	// assembled traces are emitted by a goroutine that checks for idle traces
+	var idleStopped chan struct{}
+	if traceIdle > 0 {
+		jsonSvr.traces = make(map[string]*jsonTrace)
+		idleStopped = make(chan struct{})
+		go func() {
+			defer close(idleStopped)
+			// a tiny --trace-idle still needs a positive tick
+			ticker := time.NewTicker(max(traceIdle/4, time.Millisecond))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-idleDone:
+					return
+				case <-ticker.C:
+					emitIdleTraces(traceIdle)
+				}
+			}
+		}()
+	}
+
+	runServer(config, renderJson, stop, renderJsonLogs, renderJsonMetrics)
+	stopIdle()
+
+	// the server is done, anything still pending is as idle as it'll ever be
+	if traceIdle > 0 {
+		<-idleStopped
+		emitIdleTraces(0)
+	}
 }
 
 // writeFile takes the spans and events and writes them out to json files in the
@@ -84,6 +170,15 @@ func renderJson(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_
 	// write the span to /path/tid/sid/span.json
 	writeJson(outpath, "span.json", sjs)
 
+	// write the resource the span arrived with to /path/tid/sid/resource.json
+	if outpath != "" && ss != nil {
+		rjs, err := json.Marshal(ss.Resource)
+		if err != nil {
+			log.Fatalf("failed to marshal span resource to json: %s", err)
+		}
+		writeJsonFile(outpath, "resource.json", rjs)
+	}
+
 	// only write events out if there is at least one
 	for i, e := range events {
 		ejs, err := json.Marshal(e)
@@ -97,6 +192,11 @@ func renderJson(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_
 		writeJson(outpath, filename, ejs)
 	}
 
+	trackJsonTrace(span, ss)
+	if jsonSvr.outDir != "" {
+		writeTraceIndex(span, ss)
+	}
+
 	if jsonSvr.maxSpans > 0 && jsonSvr.spansSeen >= jsonSvr.maxSpans {
 		return true // will cause the server loop to exit
 	}
@@ -104,20 +204,244 @@ func renderJson(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_
 	return false
 }
 
+// renderJsonLogs writes log records to json files. Logs that carry a trace and
+// span id go in tid/sid/log-%d.json next to their span, the rest go in
+// logs/log-%d.json.
+func renderJsonLogs(ctx context.Context, lr *logspb.LogRecord, rl *logspb.ResourceLogs, headers map[string]string, meta map[string]string) bool {
+	jsonSvr.logsSeen++
+
+	var outpath string
+	if jsonSvr.outDir != "" {
+		if len(lr.TraceId) > 0 && len(lr.SpanId) > 0 && !bytes.Equal(lr.TraceId, otlpclient.GetEmptyTraceId()) {
+			outpath = filepath.Join(jsonSvr.outDir, hex.EncodeToString(lr.TraceId), hex.EncodeToString(lr.SpanId))
+		} else {
+			outpath = filepath.Join(jsonSvr.outDir, "logs")
+		}
+		os.MkdirAll(outpath, 0755) // ignore errors for now
+	}
+
+	ljs, err := json.Marshal(lr)
+	if err != nil {
+		log.Fatalf("failed to marshal log record to json: %s", err)
+	}
+
+	writeJson(outpath, "log-"+strconv.Itoa(jsonSvr.logsSeen)+".json", ljs)
+
+	return false
+}
+
+// renderJsonMetrics writes metrics to metrics/metric-%d.json files.
+func renderJsonMetrics(ctx context.Context, metric *metricspb.Metric, rm *metricspb.ResourceMetrics, headers map[string]string, meta map[string]string) bool {
+	jsonSvr.metricsSeen++
+
+	var outpath string
+	if jsonSvr.outDir != "" {
+		outpath = filepath.Join(jsonSvr.outDir, "metrics")
+		os.MkdirAll(outpath, 0755) // ignore errors for now
+	}
+
+	mjs, err := json.Marshal(metric)
+	if err != nil {
+		log.Fatalf("failed to marshal metric to json: %s", err)
+	}
+
+	writeJson(outpath, "metric-"+strconv.Itoa(jsonSvr.metricsSeen)+".json", mjs)
+
+	return false
+}
+
 // writeJson takes a directory path, a filename, and json. When the path is not empty
 // string the json is written to path/filename. If --stdout was specified the json will
 // be printed as a line to stdout.
 func writeJson(path, filename string, js []byte) {
-	if path != "" {
-		spanfile := filepath.Join(path, filename)
-		err := os.WriteFile(spanfile, js, 0644)
-		if err != nil {
-			log.Fatalf("could not write to file %q: %s", spanfile, err)
-		}
-	}
+	writeJsonFile(path, filename, js)
 
 	if jsonSvr.stdout {
 		os.Stdout.Write(js)
 		os.Stdout.WriteString("\n")
 	}
 }
+
+// writeJsonFile writes the json to path/filename when the path is not empty
+// string. Used directly for files that should never go to stdout.
+func writeJsonFile(path, filename string, js []byte) {
+	if path == "" {
+		return
+	}
+
+	outfile := filepath.Join(path, filename)
+	err := os.WriteFile(outfile, js, 0644)
+	if err != nil {
+		log.Fatalf("could not write to file %q: %s", outfile, err)
+	}
+}
+
+// trackJsonTrace adds the span to its in-memory trace when --trace-idle
+// is on, and does nothing otherwise.
+func trackJsonTrace(span *tracepb.Span, rss *tracepb.ResourceSpans) {
+	jsonSvr.mu.Lock()
+	defer jsonSvr.mu.Unlock()
+
+	if jsonSvr.traces == nil {
+		return
+	}
+
+	tid := hex.EncodeToString(span.TraceId)
+	trace, ok := jsonSvr.traces[tid]
+	if !ok {
+		trace = &jsonTrace{TraceId: tid}
+		jsonSvr.traces[tid] = trace
+	}
+
+	var resource *resourcepb.Resource
+	if rss != nil {
+		resource = rss.Resource
+	}
+
+	// a span that comes in twice replaces the earlier copy, same as span.json
+	replaced := false
+	for i, ts := range trace.Spans {
+		if bytes.Equal(ts.Span.SpanId, span.SpanId) {
+			trace.Spans[i] = jsonTraceSpan{Span: span, Resource: resource}
+			replaced = true
+		}
+	}
+	if !replaced {
+		trace.Spans = append(trace.Spans, jsonTraceSpan{Span: span, Resource: resource})
+	}
+	trace.lastSeen = time.Now()
+}
+
+// newJsonTraceIndexEntry summarizes the span for the trace's index.
+func newJsonTraceIndexEntry(span *tracepb.Span, rss *tracepb.ResourceSpans) jsonTraceIndexEntry {
+	var service string
+	for _, attr := range rss.GetResource().GetAttributes() {
+		if attr.Key == "service.name" {
+			service = otlpclient.AnyValueToString(attr.GetValue())
+		}
+	}
+
+	return jsonTraceIndexEntry{
+		SpanId:       hex.EncodeToString(span.SpanId),
+		ParentSpanId: hex.EncodeToString(span.ParentSpanId),
+		Name:         span.Name,
+		ServiceName:  service,
+		Start:        span.StartTimeUnixNano,
+		End:          span.EndTimeUnixNano,
+		DurationNs:   int64(span.EndTimeUnixNano) - int64(span.StartTimeUnixNano),
+	}
+}
+
+// writeTraceIndex adds the span to /path/tid/index.json, keeping the spans
+// sorted by start time. The index on disk is the only copy, so it doesn't
+// cost any memory to keep an index for every trace seen.
+func writeTraceIndex(span *tracepb.Span, rss *tracepb.ResourceSpans) {
+	tid := hex.EncodeToString(span.TraceId)
+	tracedir := filepath.Join(jsonSvr.outDir, tid)
+
+	index := jsonTraceIndex{TraceId: tid}
+	if ijs, err := os.ReadFile(filepath.Join(tracedir, "index.json")); err == nil {
+		// an unreadable index is started over
+		if err := json.Unmarshal(ijs, &index); err != nil {
+			index = jsonTraceIndex{TraceId: tid}
+		}
+	}
+
+	// a span that comes in twice replaces the earlier copy, same as span.json
+	entry := newJsonTraceIndexEntry(span, rss)
+	replaced := false
+	for i, e := range index.Spans {
+		if e.SpanId == entry.SpanId {
+			index.Spans[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		index.Spans = append(index.Spans, entry)
+	}
+
+	sort.SliceStable(index.Spans, func(i, j int) bool {
+		return index.Spans[i].Start < index.Spans[j].Start
+	})
+
+	ijs, err := json.Marshal(index)
+	if err != nil {
+		log.Fatalf("failed to marshal trace index to json: %s", err)
+	}
+
+	writeJsonFile(tracedir, "index.json", ijs)
+}
+
+// emitIdleTraces writes out /path/tid/trace.json for every trace that hasn't
+// seen a span in at least idle time. Emitted traces are forgotten, so a span
+// that arrives later starts the trace over in memory and is merged into the
+// trace.json already on disk. With only --stdout it's a trace line of its own.
+func emitIdleTraces(idle time.Duration) {
+	jsonSvr.mu.Lock()
+	ready := []*jsonTrace{}
+	for tid, trace := range jsonSvr.traces {
+		if time.Since(trace.lastSeen) >= idle {
+			delete(jsonSvr.traces, tid)
+			ready = append(ready, trace)
+		}
+	}
+	jsonSvr.mu.Unlock()
+
+	for _, trace := range ready {
+		var outpath string
+		if jsonSvr.outDir != "" {
+			outpath = filepath.Join(jsonSvr.outDir, trace.TraceId)
+		}
+
+		tjs, err := mergeJsonTrace(outpath, trace)
+		if err != nil {
+			log.Fatalf("failed to marshal trace to json: %s", err)
+		}
+		writeJson(outpath, "trace.json", tjs)
+	}
+}
+
+// mergeJsonTrace returns the trace as json, including any spans from an
+// earlier emit in tracedir/trace.json that it doesn't have a newer copy of.
+// The earlier spans are kept as raw json, since the protobuf types can't be
+// unmarshaled by encoding/json.
+func mergeJsonTrace(tracedir string, trace *jsonTrace) ([]byte, error) {
+	out := struct {
+		TraceId string            `json:"trace_id"`
+		Spans   []json.RawMessage `json:"spans"`
+	}{TraceId: trace.TraceId}
+
+	seen := map[string]bool{}
+	for _, ts := range trace.Spans {
+		seen[string(ts.Span.SpanId)] = true
+	}
+
+	if tracedir != "" {
+		// an unreadable trace.json is started over, same as index.json
+		prev := struct {
+			Spans []json.RawMessage `json:"spans"`
+		}{}
+		if tjs, err := os.ReadFile(filepath.Join(tracedir, "trace.json")); err == nil && json.Unmarshal(tjs, &prev) == nil {
+			for _, raw := range prev.Spans {
+				id := struct {
+					Span struct {
+						SpanId []byte `json:"span_id"`
+					} `json:"span"`
+				}{}
+				if json.Unmarshal(raw, &id) == nil && !seen[string(id.Span.SpanId)] {
+					out.Spans = append(out.Spans, raw)
+				}
+			}
+		}
+	}
+
+	for _, ts := range trace.Spans {
+		sjs, err := json.Marshal(ts)
+		if err != nil {
+			return nil, err
+		}
+		out.Spans = append(out.Spans, sjs)
+	}
+
+	return json.Marshal(out)
+}
@@ -0,0 +1,146 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestServerJsonTraceAssembly(t *testing.T) {
+	jsonSvr.outDir = t.TempDir()
+	jsonSvr.traces = make(map[string]*jsonTrace)
+	defer func() { jsonSvr.outDir, jsonSvr.traces = "", nil }()
+
+	rss := &tracepb.ResourceSpans{
+		Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": "test-svc"}),
+		},
+	}
+
+	parent := otlpclient.NewProtobufSpan()
+	parent.TraceId = otlpclient.GenerateTraceId()
+	parent.SpanId = otlpclient.GenerateSpanId()
+	parent.Name = "parent"
+	parent.EndTimeUnixNano = parent.StartTimeUnixNano + 1000
+
+	child := otlpclient.NewProtobufSpan()
+	child.TraceId = parent.TraceId
+	child.SpanId = otlpclient.GenerateSpanId()
+	child.ParentSpanId = parent.SpanId
+	child.Name = "child"
+	child.StartTimeUnixNano = parent.StartTimeUnixNano + 100
+	child.EndTimeUnixNano = parent.StartTimeUnixNano + 600
+
+	// child arrives first, the way it usually does
+	renderJson(t.Context(), child, child.Events, rss, nil, nil)
+	renderJson(t.Context(), parent, parent.Events, rss, nil, nil)
+
+	tid := hex.EncodeToString(parent.TraceId)
+	tracedir := filepath.Join(jsonSvr.outDir, tid)
+
+	ijs, err := os.ReadFile(filepath.Join(tracedir, "index.json"))
+	if err != nil {
+		t.Fatalf("failed to read index.json: %s", err)
+	}
+	index := jsonTraceIndex{}
+	if err := json.Unmarshal(ijs, &index); err != nil {
+		t.Fatalf("failed to parse index.json: %s", err)
+	}
+	if len(index.Spans) != 2 {
+		t.Fatalf("expected 2 spans in index but got %d", len(index.Spans))
+	}
+	if index.Spans[0].Name != "parent" || index.Spans[1].Name != "child" {
+		t.Errorf("index spans not sorted by start time: %+v", index.Spans)
+	}
+	if index.Spans[1].ParentSpanId != hex.EncodeToString(parent.SpanId) {
+		t.Errorf("child span parent link is wrong, got %q", index.Spans[1].ParentSpanId)
+	}
+	if index.Spans[1].ServiceName != "test-svc" {
+		t.Errorf("expected service name test-svc but got %q", index.Spans[1].ServiceName)
+	}
+	if index.Spans[1].DurationNs != 500 {
+		t.Errorf("expected child duration 500ns but got %d", index.Spans[1].DurationNs)
+	}
+
+	if _, err := os.Stat(filepath.Join(tracedir, hex.EncodeToString(child.SpanId), "resource.json")); err != nil {
+		t.Errorf("resource.json was not written: %s", err)
+	}
+
+	emitIdleTraces(0)
+	tjs, err := os.ReadFile(filepath.Join(tracedir, "trace.json"))
+	if err != nil {
+		t.Fatalf("failed to read trace.json: %s", err)
+	}
+	trace := struct {
+		TraceId string            `json:"trace_id"`
+		Spans   []json.RawMessage `json:"spans"`
+	}{}
+	if err := json.Unmarshal(tjs, &trace); err != nil {
+		t.Fatalf("failed to parse trace.json: %s", err)
+	}
+	if trace.TraceId != tid || len(trace.Spans) != 2 {
+		t.Errorf("assembled trace is wrong, got trace id %q with %d spans", trace.TraceId, len(trace.Spans))
+	}
+	if len(jsonSvr.traces) != 0 {
+		t.Errorf("emitted trace should have been evicted but %d traces are held", len(jsonSvr.traces))
+	}
+
+	// spans after the trace was emitted are added to its trace.json, and one
+	// that comes in again replaces its earlier copy
+	late := otlpclient.NewProtobufSpan()
+	late.TraceId = parent.TraceId
+	late.SpanId = otlpclient.GenerateSpanId()
+	late.Name = "late"
+	renderJson(t.Context(), late, late.Events, rss, nil, nil)
+	renderJson(t.Context(), parent, parent.Events, rss, nil, nil)
+	emitIdleTraces(0)
+
+	tjs, err = os.ReadFile(filepath.Join(tracedir, "trace.json"))
+	if err != nil {
+		t.Fatalf("failed to read trace.json: %s", err)
+	}
+	if err := json.Unmarshal(tjs, &trace); err != nil {
+		t.Fatalf("failed to parse trace.json: %s", err)
+	}
+	if len(trace.Spans) != 3 {
+		t.Errorf("expected the late span merged into trace.json for 3 spans but got %d", len(trace.Spans))
+	}
+}
+
+func TestServerJsonIndexWithoutTracking(t *testing.T) {
+	jsonSvr.outDir = t.TempDir()
+	jsonSvr.traces = nil // no --trace-idle
+	defer func() { jsonSvr.outDir = "" }()
+
+	first := otlpclient.NewProtobufSpan()
+	first.TraceId = otlpclient.GenerateTraceId()
+	first.SpanId = otlpclient.GenerateSpanId()
+	first.Name = "first"
+	second := otlpclient.NewProtobufSpan()
+	second.TraceId = first.TraceId
+	second.SpanId = otlpclient.GenerateSpanId()
+	second.Name = "second"
+	second.StartTimeUnixNano = first.StartTimeUnixNano + 100
+
+	renderJson(t.Context(), first, first.Events, nil, nil, nil)
+	renderJson(t.Context(), second, second.Events, nil, nil, nil)
+	renderJson(t.Context(), first, first.Events, nil, nil, nil) // repeats replace
+
+	ijs, err := os.ReadFile(filepath.Join(jsonSvr.outDir, hex.EncodeToString(first.TraceId), "index.json"))
+	if err != nil {
+		t.Fatalf("failed to read index.json: %s", err)
+	}
+	index := jsonTraceIndex{}
+	if err := json.Unmarshal(ijs, &index); err != nil {
+		t.Fatalf("failed to parse index.json: %s", err)
+	}
+	if len(index.Spans) != 2 || index.Spans[0].Name != "first" || index.Spans[1].Name != "second" {
+		t.Errorf("index built from disk is wrong: %+v", index.Spans)
+	}
+}
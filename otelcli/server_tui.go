@@ -7,18 +7,24 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/tobert/otel-cli/otlpclient"
 	"github.com/tobert/otel-cli/otlpserver"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
+// tuiServer is only touched by the server callbacks, which otlpserver runs
+// one at a time in arrival order, so it needs no locking.
 var tuiServer struct {
-	lines  SpanEventUnionList
-	traces map[string]*tracepb.Span // for looking up top span of trace by trace id
-	area   *pterm.AreaPrinter
+	lines   SpanEventUnionList
+	traces  map[string]*tracepb.Span     // for looking up top span of trace by trace id
+	metrics map[string]*metricspb.Metric // metric on screen by resource and name
+	area    *pterm.AreaPrinter
 }
 
 func serverTuiCmd(config *Config) *cobra.Command {
@@ -33,6 +39,7 @@ func serverTuiCmd(config *Config) *cobra.Command {
 	}
 
 	addCommonParams(&cmd, config)
+	addServerParams(&cmd, config)
 	return &cmd
 }
 
@@ -47,12 +54,13 @@ func doServerTui(cmd *cobra.Command, args []string) {
 
 	tuiServer.lines = []SpanEventUnion{}
 	tuiServer.traces = make(map[string]*tracepb.Span)
+	tuiServer.metrics = make(map[string]*metricspb.Metric)
 
 	stop := func(otlpserver.OtlpServer) {
 		tuiServer.area.Stop()
 	}
 
-	runServer(config, renderTui, stop)
+	runServer(config, renderTui, stop, renderTuiLogs, renderTuiMetrics)
 }
 
 // renderTui takes the given span and events, appends them to the in-memory
@@ -67,7 +75,54 @@ func renderTui(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_E
 	for _, e := range events {
 		tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Span: span, Event: e})
 	}
+	drawTui()
+	return false // keep running until user hits ctrl-c
+}
+
+// renderTuiLogs appends log records to the in-memory event list, where
+// drawTui puts them under the span they were emitted in, then redraws the table.
+func renderTuiLogs(ctx context.Context, lr *logspb.LogRecord, rl *logspb.ResourceLogs, headers map[string]string, meta map[string]string) bool {
+	tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Log: lr})
+	drawTui()
+	return false
+}
+
+// renderTuiMetrics appends metrics to the in-memory event list, each as one
+// row with its most recent value, then redraws the table.
+func renderTuiMetrics(ctx context.Context, metric *metricspb.Metric, rm *metricspb.ResourceMetrics, headers map[string]string, meta map[string]string) bool {
+	addTuiMetric(metric, rm)
+	drawTui()
+	return false
+}
+
+// addTuiMetric replaces the row for the same metric from the same resource,
+// so a periodic exporter updates one row instead of adding one per export.
+// A row that was trimmed off the screen is added back.
+func addTuiMetric(metric *metricspb.Metric, rm *metricspb.ResourceMetrics) {
+	attrs := map[string]string{}
+	for _, attr := range rm.GetResource().GetAttributes() {
+		attrs[attr.Key] = otlpclient.AnyValueToString(attr.GetValue())
+	}
+	key := flattenStringMap(attrs, "") + "/" + metric.Name
+
+	if prev, ok := tuiServer.metrics[key]; ok {
+		for i, line := range tuiServer.lines {
+			if line.Metric == prev {
+				tuiServer.lines[i].Metric = metric
+				tuiServer.metrics[key] = metric
+				return
+			}
+		}
+	}
+
+	tuiServer.metrics[key] = metric
+	tuiServer.lines = append(tuiServer.lines, SpanEventUnion{Metric: metric})
+}
+
+// drawTui sorts and trims the in-memory event list then prints it as a pterm table.
+func drawTui() {
 	sort.Sort(tuiServer.lines)
+	tuiServer.lines = groupTuiLogs(tuiServer.lines)
 	trimTuiEvents()
 
 	td := pterm.TableData{
@@ -96,6 +151,24 @@ func renderTui(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_E
 			}
 
 			elapsed = endOffset - startOffset
+		} else if line.IsLog() {
+			name = logBodyString(line.Log)
+			kind = "log"
+			if sev := line.Log.SeverityText; sev != "" {
+				kind = "log:" + sev
+			}
+			parent = line.SpanIdString()
+			if tspan, ok := tuiServer.traces[line.TraceIdString()]; ok {
+				startOffset = roundedDelta(line.UnixNanos(), tspan.StartTimeUnixNano)
+			}
+			endOffset = startOffset
+			elapsed = 0
+		} else if line.IsMetric() {
+			name = line.Metric.Name
+			if value := metricValueString(line.Metric); value != "" {
+				name += " = " + value
+			}
+			kind = "metric"
 		} else { // span events
 			name = line.Event.Name
 			kind = "event"
@@ -123,7 +196,103 @@ func renderTui(ctx context.Context, span *tracepb.Span, events []*tracepb.Span_E
 	}
 
 	tuiServer.area.Update(pterm.DefaultTable.WithHasHeader().WithData(td).Srender())
-	return false // keep running until user hits ctrl-c
+}
+
+// logBodyString returns the log record's body as a single line that fits
+// reasonably in a table cell.
+func logBodyString(lr *logspb.LogRecord) string {
+	if lr.Body == nil {
+		return ""
+	}
+	body := strings.ReplaceAll(otlpclient.AnyValueToString(lr.Body), "\n", " ")
+	if len(body) > 80 {
+		body = body[:77] + "..."
+	}
+	return body
+}
+
+// groupTuiLogs moves each log record that was emitted in a span to just
+// under that span's row, keeping those logs in time order. Logs outside of
+// a span, or whose span hasn't arrived yet, stay where their time puts them.
+func groupTuiLogs(lines SpanEventUnionList) SpanEventUnionList {
+	spans := make(map[string]bool)
+	for _, line := range lines {
+		if line.IsSpan() {
+			spans[line.TraceIdString()+line.SpanIdString()] = true
+		}
+	}
+
+	logs := make(map[string][]SpanEventUnion)
+	for _, line := range lines {
+		if key := line.TraceIdString() + line.SpanIdString(); line.IsLog() && spans[key] {
+			logs[key] = append(logs[key], line)
+		}
+	}
+
+	out := make(SpanEventUnionList, 0, len(lines))
+	for _, line := range lines {
+		key := line.TraceIdString() + line.SpanIdString()
+		if line.IsLog() && spans[key] {
+			continue // already placed under its span
+		}
+		out = append(out, line)
+		if line.IsSpan() {
+			out = append(out, logs[key]...)
+		}
+	}
+	return out
+}
+
+// metricValueString returns the value of the metric's most recent data point
+// for gauges and sums, and "" for the other types.
+func metricValueString(metric *metricspb.Metric) string {
+	var points []*metricspb.NumberDataPoint
+	if g := metric.GetGauge(); g != nil {
+		points = g.DataPoints
+	} else if s := metric.GetSum(); s != nil {
+		points = s.DataPoints
+	}
+
+	var latest *metricspb.NumberDataPoint
+	for _, dp := range points {
+		if latest == nil || dp.TimeUnixNano >= latest.TimeUnixNano {
+			latest = dp
+		}
+	}
+
+	switch v := latest.GetValue().(type) {
+	case *metricspb.NumberDataPoint_AsInt:
+		return strconv.FormatInt(v.AsInt, 10)
+	case *metricspb.NumberDataPoint_AsDouble:
+		return strconv.FormatFloat(v.AsDouble, 'g', -1, 64)
+	}
+	return ""
+}
+
+// metricTimeUnixNano returns the time of the metric's most recent data point.
+func metricTimeUnixNano(metric *metricspb.Metric) uint64 {
+	var ts []uint64
+	for _, dp := range metric.GetGauge().GetDataPoints() {
+		ts = append(ts, dp.TimeUnixNano)
+	}
+	for _, dp := range metric.GetSum().GetDataPoints() {
+		ts = append(ts, dp.TimeUnixNano)
+	}
+	for _, dp := range metric.GetHistogram().GetDataPoints() {
+		ts = append(ts, dp.TimeUnixNano)
+	}
+	for _, dp := range metric.GetExponentialHistogram().GetDataPoints() {
+		ts = append(ts, dp.TimeUnixNano)
+	}
+	for _, dp := range metric.GetSummary().GetDataPoints() {
+		ts = append(ts, dp.TimeUnixNano)
+	}
+
+	var latest uint64
+	for _, t := range ts {
+		latest = max(latest, t)
+	}
+	return latest
 }
 
 // roundedDelta takes to uint64 nanos values, cuts them down to milliseconds,
@@ -166,27 +335,61 @@ func trimTuiEvents() {
 	tuiServer.lines = tuiServer.lines[end:]
 }
 
-// SpanEventUnion is for server_tui so it can sort spans and events together
-// by timestamp.
+// SpanEventUnion is for server_tui so it can sort spans, events, logs, and
+// metrics together by timestamp.
 type SpanEventUnion struct {
-	Span  *tracepb.Span
-	Event *tracepb.Span_Event
+	Span   *tracepb.Span
+	Event  *tracepb.Span_Event
+	Log    *logspb.LogRecord
+	Metric *metricspb.Metric
+}
+
+func (seu *SpanEventUnion) TraceIdString() string {
+	if seu.IsLog() {
+		return hex.EncodeToString(seu.Log.TraceId)
+	} else if seu.IsMetric() {
+		return ""
+	}
+	return hex.EncodeToString(seu.Span.TraceId)
 }
 
-func (seu *SpanEventUnion) TraceIdString() string { return hex.EncodeToString(seu.Span.TraceId) }
-func (seu *SpanEventUnion) SpanIdString() string  { return hex.EncodeToString(seu.Span.SpanId) }
+func (seu *SpanEventUnion) SpanIdString() string {
+	if seu.IsLog() {
+		return hex.EncodeToString(seu.Log.SpanId)
+	} else if seu.IsMetric() {
+		return ""
+	}
+	return hex.EncodeToString(seu.Span.SpanId)
+}
 
 func (seu *SpanEventUnion) UnixNanos() uint64 {
-	if seu.IsSpan() {
+	if seu.IsMetric() {
+		return metricTimeUnixNano(seu.Metric)
+	} else if seu.IsLog() {
+		// time is optional on log records, observed time is always set
+		if seu.Log.TimeUnixNano != 0 {
+			return seu.Log.TimeUnixNano
+		}
+		return seu.Log.ObservedTimeUnixNano
+	} else if seu.IsSpan() {
 		return seu.Span.StartTimeUnixNano
 	} else {
 		return seu.Event.TimeUnixNano
 	}
 }
 
-// IsSpan returns true if this union is for an event. Span is always populated
-// but Event is only populated for events.
-func (seu *SpanEventUnion) IsSpan() bool { return seu.Event == nil }
+// IsSpan returns true if this union is for a span. Span is populated for
+// spans and events, Event only for events, Log only for log records, and
+// Metric only for metrics.
+func (seu *SpanEventUnion) IsSpan() bool {
+	return seu.Event == nil && seu.Log == nil && seu.Metric == nil
+}
+
+// IsLog returns true if this union is for a log record.
+func (seu *SpanEventUnion) IsLog() bool { return seu.Log != nil }
+
+// IsMetric returns true if this union is for a metric.
+func (seu *SpanEventUnion) IsMetric() bool { return seu.Metric != nil }
 
 // SpanEventUnionList is a sortable list of SpanEventUnion, sorted on timestamp.
 type SpanEventUnionList []SpanEventUnion
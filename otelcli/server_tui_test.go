@@ -0,0 +1,104 @@
+package otelcli
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+func TestGroupTuiLogs(t *testing.T) {
+	first := otlpclient.NewProtobufSpan()
+	first.TraceId = otlpclient.GenerateTraceId()
+	first.SpanId = otlpclient.GenerateSpanId()
+	second := otlpclient.NewProtobufSpan()
+	second.TraceId = first.TraceId
+	second.SpanId = otlpclient.GenerateSpanId()
+	second.StartTimeUnixNano = first.StartTimeUnixNano + 100
+
+	// the first span's log comes after the second span starts
+	inFirst := &logspb.LogRecord{TraceId: first.TraceId, SpanId: first.SpanId, TimeUnixNano: first.StartTimeUnixNano + 200}
+	unrelated := &logspb.LogRecord{TimeUnixNano: first.StartTimeUnixNano + 50}
+	metric := &metricspb.Metric{Name: "m", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{{TimeUnixNano: first.StartTimeUnixNano + 300}},
+	}}}
+
+	lines := SpanEventUnionList{
+		{Log: inFirst}, {Span: second}, {Metric: metric}, {Log: unrelated}, {Span: first},
+	}
+	sort.Sort(lines)
+	lines = groupTuiLogs(lines)
+
+	want := []string{"span:first", "log:inFirst", "log:unrelated", "span:second", "metric"}
+	got := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case line.IsMetric():
+			got[i] = "metric"
+		case line.Log == inFirst:
+			got[i] = "log:inFirst"
+		case line.Log == unrelated:
+			got[i] = "log:unrelated"
+		case line.Span == first:
+			got[i] = "span:first"
+		case line.Span == second:
+			got[i] = "span:second"
+		}
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected lines in order %v but got %v", want, got)
+		}
+	}
+}
+
+func TestMetricValueString(t *testing.T) {
+	sum := &metricspb.Metric{Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+		DataPoints: []*metricspb.NumberDataPoint{
+			{TimeUnixNano: 2, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 7}},
+			{TimeUnixNano: 1, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 3}},
+		},
+	}}}
+	if v := metricValueString(sum); v != "7" {
+		t.Errorf("expected the latest sum value 7 but got %q", v)
+	}
+
+	gauge := &metricspb.Metric{Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 0.5}}},
+	}}}
+	if v := metricValueString(gauge); v != "0.5" {
+		t.Errorf("expected gauge value 0.5 but got %q", v)
+	}
+
+	if v := metricValueString(&metricspb.Metric{Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{}}}); v != "" {
+		t.Errorf("expected no value for a histogram but got %q", v)
+	}
+}
+
+func TestAddTuiMetric(t *testing.T) {
+	tuiServer.lines = SpanEventUnionList{}
+	tuiServer.metrics = make(map[string]*metricspb.Metric)
+	defer func() { tuiServer.lines, tuiServer.metrics = nil, nil }()
+
+	resource := func(service string) *metricspb.ResourceMetrics {
+		return &metricspb.ResourceMetrics{Resource: &resourcepb.Resource{
+			Attributes: otlpclient.StringMapAttrsToProtobuf(map[string]string{"service.name": service}),
+		}}
+	}
+
+	first := &metricspb.Metric{Name: "requests"}
+	latest := &metricspb.Metric{Name: "requests"}
+	addTuiMetric(first, resource("a"))
+	addTuiMetric(&metricspb.Metric{Name: "requests"}, resource("b"))
+	addTuiMetric(latest, resource("a"))
+
+	if len(tuiServer.lines) != 2 {
+		t.Fatalf("expected one row per resource and metric name but got %d rows", len(tuiServer.lines))
+	}
+	if tuiServer.lines[0].Metric != latest {
+		t.Errorf("expected the row to be updated to the latest export")
+	}
+}
@@ -42,6 +42,8 @@ Example:
 	cmd.AddCommand(spanBgCmd(config))
 	cmd.AddCommand(spanEventCmd(config))
 	cmd.AddCommand(spanEndCmd(config))
+	cmd.AddCommand(spanStartCmd(config))
+	cmd.AddCommand(spanFinishCmd(config))
 
 	return &cmd
 }
@@ -54,8 +56,8 @@ func doSpan(cmd *cobra.Command, args []string) {
 	ctx, client := StartClient(ctx, config)
 	span := config.NewProtobufSpan()
 	ctx, err := otlpclient.SendSpan(ctx, client, config, span)
-	config.SoftFailIfErr(err)
-	_, err = client.Stop(ctx)
-	config.SoftFailIfErr(err)
-	config.PropagateTraceparent(span, os.Stdout)
+	config.failExportIfErr(err)
+	ctx, err = client.Stop(ctx)
+	config.failExportIfErr(err)
+	config.PropagateTraceparent(ctx, span, os.Stdout)
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"os"
 	"os/signal"
-	"path"
 	"strconv"
 	"syscall"
 	"time"
@@ -47,16 +46,23 @@ timeout, (catchable) signals, or deliberate exit.
 	// start a background span at the top of a script then let it fall off
 	// at the end to get an easy span
 	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "a name for the background span, so more than one can share a sockdir")
 
 	cmd.Flags().IntVar(&config.BackgroundParentPollMs, "parent-poll", defaults.BackgroundParentPollMs, "number of milliseconds to wait between checking for whether the parent process exited")
 	cmd.Flags().BoolVar(&config.BackgroundWait, "wait", defaults.BackgroundWait, "wait for background to be fully started and then return")
 	cmd.Flags().BoolVar(&config.BackgroundSkipParentPidCheck, "skip-pid-check", defaults.BackgroundSkipParentPidCheck, "disable checking parent pid")
+	cmd.Flags().BoolVar(&config.BackgroundSendSteps, "send-steps", defaults.BackgroundSendSteps, "send each step as it ends instead of all of them with the background span")
 
 	addCommonParams(&cmd, config)
 	addSpanParams(&cmd, config)
 	addClientParams(&cmd, config)
 	addAttrParams(&cmd, config)
 
+	cmd.AddCommand(spanBgStepCmd(config))
+	cmd.AddCommand(spanBgListCmd(config))
+	cmd.AddCommand(spanBgSetCmd(config))
+	cmd.AddCommand(spanBgGetCmd(config))
+
 	return &cmd
 }
 
@@ -80,14 +86,15 @@ func doSpanBackground(cmd *cobra.Command, args []string) {
 	}
 
 	span := config.NewProtobufSpan()
+	span.EndTimeUnixNano = 0 // it hasn't ended yet, see below
 
 	// span background is a bit different from span/exec in that it might be
 	// hanging out while other spans are created, so it does the traceparent
 	// propagation before the server starts, instead of after
-	config.PropagateTraceparent(span, os.Stdout)
+	config.PropagateTraceparent(ctx, span, os.Stdout)
 
-	sockfile := path.Join(config.BackgroundSockdir, spanBgSockfilename)
-	bgs := createBgServer(ctx, sockfile, span)
+	sockfile := bgSockfile(config)
+	bgs := createBgServer(ctx, sockfile, span, client)
 
 	// set up signal handlers to cleanly exit on SIGINT/SIGTERM etc
 	signals := make(chan os.Signal, 1)
@@ -111,8 +118,11 @@ func doSpanBackground(cmd *cobra.Command, args []string) {
 				cppid := os.Getppid()
 				if cppid != ppid {
 					rt := time.Since(started)
+					bgs.mu.Lock()
 					spanBgEndEvent(ctx, span, "parent_exited", rt)
+					bgs.mu.Unlock()
 					bgs.Shutdown()
+					return
 				}
 			}
 		}()
@@ -124,7 +134,9 @@ func doSpanBackground(cmd *cobra.Command, args []string) {
 		go func() {
 			time.Sleep(timeout)
 			rt := time.Since(started)
+			bgs.mu.Lock()
 			spanBgEndEvent(ctx, span, "timeout", rt)
+			bgs.mu.Unlock()
 			bgs.Shutdown()
 		}()
 	}
@@ -132,12 +144,22 @@ func doSpanBackground(cmd *cobra.Command, args []string) {
 	// will block until bgs.Shutdown()
 	bgs.Run()
 
-	span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+	// connections still open can keep calling RPCs, hold the lock from here
+	// on so they wait until the span is sent
+	bgs.mu.Lock()
+	defer bgs.mu.Unlock()
+
+	// span end sets the end time, the other ways out of Run() don't
+	if span.EndTimeUnixNano == 0 {
+		span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+	}
 
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
 	defer cancel()
 
-	_, err := otlpclient.SendSpan(ctx, client, config, span)
+	// steps that haven't been sent go with it, and any still open end now
+	spans := append(bgs.steps.finish(time.Unix(0, int64(span.EndTimeUnixNano))), span)
+	_, err := otlpclient.SendSpans(ctx, client, config, spans)
 	if err != nil {
 		config.SoftFail("Sending span failed: %s", err)
 	}
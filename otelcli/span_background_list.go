@@ -0,0 +1,107 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// spanBgListCmd represents the span background list command
+func spanBgListCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list",
+		Short: "list the background spans running in a sockdir",
+		Long: `List the background spans listening in --sockdir, one per line with the
+--bg-name, traceparent, and span name. The one started without --bg-name is
+listed as "-". With --output json each line is a JSON object instead.
+
+	otel-cli span background --sockdir $sd --bg-name build &
+	otel-cli span background --sockdir $sd --bg-name deploy &
+	otel-cli span background list --sockdir $sd
+`,
+		Run:  doSpanBgList,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.MarkFlagRequired("sockdir")
+
+	return &cmd
+}
+
+func doSpanBgList(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	spans, err := listBgSpans(config)
+	config.SoftFailIfErr(err)
+	config.printBgSpanList(spans, os.Stdout)
+}
+
+// listBgSpans asks each background span with a socket in --sockdir for its
+// ids, sorted by --bg-name. Sockets left behind by background spans that
+// aren't running anymore are skipped.
+func listBgSpans(config Config) ([]BgSpan, error) {
+	// the unnamed span's socket, then one for each --bg-name
+	files, err := filepath.Glob(filepath.Join(config.BackgroundSockdir, spanBgSockfilename))
+	if err != nil {
+		return nil, err
+	}
+	named, err := filepath.Glob(filepath.Join(config.BackgroundSockdir, spanBgSockPrefix+"*.sock"))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, named...)
+
+	spans := []BgSpan{}
+	for _, file := range files {
+		conn, err := net.DialTimeout("unix", file, config.GetTimeout())
+		if err != nil {
+			config.SoftLog("skipping background socket %q: %s", file, err)
+			continue
+		}
+		conn.SetDeadline(time.Now().Add(config.GetTimeout()))
+
+		client := jsonrpc.NewClient(conn)
+		res := BgSpan{}
+		err = client.Call("BgSpan.Info", &struct{}{}, &res)
+		client.Close()
+		if err != nil {
+			config.SoftLog("skipping background socket %q: %s", file, err)
+			continue
+		}
+		spans = append(spans, res)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].BgName < spans[j].BgName })
+	return spans, nil
+}
+
+// printBgSpanList prints the background spans as tab-separated text, or as
+// a JSON object per line with --output json.
+func (c Config) printBgSpanList(spans []BgSpan, target io.Writer) {
+	for _, bs := range spans {
+		if c.Output == "json" {
+			js, err := json.Marshal(bs)
+			c.SoftFailIfErr(err)
+			fmt.Fprintln(target, string(js))
+			continue
+		}
+
+		name := bs.BgName
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(target, "%s\t%s\t%s\n", name, bs.Traceparent, bs.Name)
+	}
+}
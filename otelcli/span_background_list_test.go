@@ -0,0 +1,100 @@
+package otelcli
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBgSockfile(t *testing.T) {
+	config := DefaultConfig().WithBackgroundSockdir("/tmp/sd")
+	if got := bgSockfile(config); got != "/tmp/sd/otel-cli-background.sock" {
+		t.Errorf("unexpected socket file without --bg-name: %q", got)
+	}
+	if got := bgSockfile(config.WithBackgroundName("deploy.prod")); got != "/tmp/sd/otel-cli-background-deploy.prod.sock" {
+		t.Errorf("unexpected socket file with --bg-name: %q", got)
+	}
+}
+
+func TestRemoveStaleBgSock(t *testing.T) {
+	sockfile := filepath.Join(t.TempDir(), spanBgSockfilename)
+
+	// nothing there yet is fine
+	if err := removeStaleBgSock(sockfile, time.Second); err != nil {
+		t.Errorf("expected no error without a socket, got %s", err)
+	}
+
+	listener, err := net.Listen("unix", sockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := removeStaleBgSock(sockfile, time.Second); err == nil {
+		t.Error("expected an error for a socket that's still answering")
+	}
+	if _, err := os.Stat(sockfile); err != nil {
+		t.Errorf("a live socket should be left alone, got %s", err)
+	}
+
+	// what a background span that was killed leaves behind
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	if err := removeStaleBgSock(sockfile, time.Second); err != nil {
+		t.Errorf("expected a stale socket to be cleaned up, got %s", err)
+	}
+	if _, err := os.Stat(sockfile); !os.IsNotExist(err) {
+		t.Errorf("expected the stale socket to be removed, got %v", err)
+	}
+}
+
+func TestListBgSpans(t *testing.T) {
+	sockdir := t.TempDir()
+	config := DefaultConfig().WithEndpoint("localhost:4317").WithBackgroundSockdir(sockdir)
+
+	// two background spans in the same process and sockdir, each with its
+	// own rpc server
+	servers := []*bgServer{}
+	for _, name := range []string{"beta", ""} {
+		c := config.WithBackgroundName(name).WithSpanName("script " + name)
+		ctx := context.WithValue(context.Background(), configContextKey(), &c)
+		bgs := createBgServer(ctx, bgSockfile(c), c.NewProtobufSpan(), nil)
+		go bgs.Run()
+		servers = append(servers, bgs)
+	}
+	defer func() {
+		for _, bgs := range servers {
+			bgs.Shutdown()
+		}
+	}()
+
+	// a socket left behind by a background span that's gone is skipped
+	stale := filepath.Join(sockdir, "otel-cli-background-stale.sock")
+	if err := os.WriteFile(stale, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	spans, err := listBgSpans(config)
+	if err != nil {
+		t.Fatalf("listing background spans failed: %s", err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 background spans, got %d", len(spans))
+	}
+	if spans[0].BgName != "" || spans[0].Name != "script " || spans[1].BgName != "beta" || spans[1].Name != "script beta" {
+		t.Errorf("unexpected background spans: %+v", spans)
+	}
+	if spans[0].SpanID == spans[1].SpanID {
+		t.Error("expected each background span to reply for itself")
+	}
+
+	out := bytes.Buffer{}
+	config.printBgSpanList(spans, &out)
+	want := "-\t" + spans[0].Traceparent + "\tscript \n" +
+		"beta\t" + spans[1].Traceparent + "\tscript beta\n"
+	if out.String() != want {
+		t.Errorf("unexpected list output, got %q, wanted %q", out.String(), want)
+	}
+}
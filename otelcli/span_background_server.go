@@ -3,28 +3,49 @@ package otelcli
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
 	"os"
 	"path"
+	"regexp"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/tobert/otel-cli/otlpclient"
+	"github.com/tobert/otel-cli/w3c/traceparent"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
 )
 
+// bgNameRe matches the names --bg-name accepts, which go in socket filenames.
+var bgNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 // BgSpan is what is returned to all RPC clients and its methods are exported.
+// The server handles each connection in its own goroutine, so methods that
+// touch span hold mu.
 type BgSpan struct {
-	TraceID     string `json:"trace_id"`
-	SpanID      string `json:"span_id"`
-	Traceparent string `json:"traceparent"`
-	Error       string `json:"error"`
-	config      Config
-	span        *tracepb.Span
-	shutdown    func()
+	TraceID      string `json:"trace_id"`
+	SpanID       string `json:"span_id"`
+	ParentSpanID string `json:"parent_span_id"`
+	Traceparent  string `json:"traceparent"`
+	StartTime    uint64 `json:"start_time_unix_nano"`
+	EndTime      uint64 `json:"end_time_unix_nano"`
+	Name         string `json:"name"`
+	BgName       string `json:"bg_name"`
+	Error        string `json:"error"`
+	config       Config
+	span         *tracepb.Span
+	mu           *sync.Mutex // guards span, shared with the bgServer
+	steps        *bgSteps
+	sendSteps    func([]*tracepb.Span) error // nil unless --send-steps
+	shutdown     func()
 }
 
 // BgSpanEvent is a span event that the client will send.
@@ -34,18 +55,89 @@ type BgSpanEvent struct {
 	Attributes map[string]string
 }
 
-// BgEnd is an empty struct that can be sent to call End().
+// BgEnd is sent to call End(). Timestamp is the end time of the span, it
+// ends now when it's empty and no end time was set with SetEnd().
 type BgEnd struct {
 	Attributes map[string]string `json:"span_attributes" env:"OTEL_CLI_ATTRIBUTES"`
 	StatusCode string            `json:"status_code"`
 	StatusDesc string            `json:"status_description"`
+	Timestamp  string            `json:"timestamp"`
+}
+
+// BgAttributes are attributes to set on the span with SetAttributes().
+type BgAttributes struct {
+	Attributes map[string]string `json:"attributes"`
+}
+
+// BgAttributeKeys are the keys of attributes to remove from the span with
+// RemoveAttributes().
+type BgAttributeKeys struct {
+	Keys []string `json:"keys"`
+}
+
+// BgRename is the new name of the span for Rename().
+type BgRename struct {
+	Name string `json:"name"`
+}
+
+// BgKind is the new kind of the span for SetKind(), e.g. server.
+type BgKind struct {
+	Kind string `json:"kind"`
+}
+
+// BgStatus is the new status of the span for SetStatus().
+type BgStatus struct {
+	StatusCode string `json:"status_code"`
+	StatusDesc string `json:"status_description"`
+}
+
+// BgTimestamp is an RFC3339Nano timestamp for SetStart() and SetEnd().
+type BgTimestamp struct {
+	Timestamp string `json:"timestamp"`
+}
+
+// BgLink links the span to the span in Traceparent with AddLink().
+type BgLink struct {
+	Traceparent string            `json:"traceparent"`
+	Attributes  map[string]string `json:"attributes"`
+}
+
+// BgSet is every change for Set() at once. Nil and empty fields are left alone.
+type BgSet struct {
+	Rename      *BgRename        `json:"rename"`
+	Kind        *BgKind          `json:"kind"`
+	Start       *BgTimestamp     `json:"start"`
+	End         *BgTimestamp     `json:"end"`
+	RemoveAttrs *BgAttributeKeys `json:"remove_attributes"`
+	Attributes  *BgAttributes    `json:"attributes"`
+	Status      *BgStatus        `json:"status"`
+	Links       []BgLink         `json:"links"`
+}
+
+// BgStep starts a step, a child span of the background span or of another
+// step, named by its span id or name in Parent.
+type BgStep struct {
+	Name       string            `json:"name"`
+	Parent     string            `json:"parent"`
+	Timestamp  string            `json:"timestamp"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// BgStepEnd ends the step with the span id or name in Step, or the newest
+// open step when it's empty.
+type BgStepEnd struct {
+	Step       string            `json:"step"`
+	Timestamp  string            `json:"timestamp"`
+	Attributes map[string]string `json:"attributes"`
+	StatusCode string            `json:"status_code"`
+	StatusDesc string            `json:"status_description"`
 }
 
 // AddEvent takes a BgSpanEvent from the client and attaches an event to the span.
 func (bs BgSpan) AddEvent(bse *BgSpanEvent, reply *BgSpan) error {
-	reply.TraceID = hex.EncodeToString(bs.span.TraceId)
-	reply.SpanID = hex.EncodeToString(bs.span.SpanId)
-	reply.Traceparent = otlpclient.TraceparentFromProtobufSpan(bs.span, bs.config.GetIsRecording()).Encode()
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.fillReply(bs.span, reply)
 
 	ts, err := time.Parse(time.RFC3339Nano, bse.Timestamp)
 	if err != nil {
@@ -63,6 +155,298 @@ func (bs BgSpan) AddEvent(bse *BgSpanEvent, reply *BgSpan) error {
 	return nil
 }
 
+// fillReply copies the ids and times of span, the background span or one
+// of its steps, into reply.
+func (bs BgSpan) fillReply(span *tracepb.Span, reply *BgSpan) {
+	reply.TraceID = hex.EncodeToString(span.TraceId)
+	reply.SpanID = hex.EncodeToString(span.SpanId)
+	reply.ParentSpanID = hex.EncodeToString(span.ParentSpanId)
+	reply.Traceparent = otlpclient.TraceparentFromProtobufSpan(span, bs.config.GetIsRecording()).Encode()
+	reply.StartTime = span.StartTimeUnixNano
+	reply.EndTime = span.EndTimeUnixNano
+	reply.Name = span.Name
+}
+
+// output returns the spanOutput for a reply from the background server.
+func (bs BgSpan) output(ctx context.Context, config Config) spanOutput {
+	tp, err := traceparent.Parse(bs.Traceparent)
+	if err != nil {
+		config.SoftFail("Could not parse traceparent: %s", err)
+	}
+	parent, _ := hex.DecodeString(bs.ParentSpanID)
+	return newSpanOutput(ctx, config, tp, parent, bs.StartTime, bs.EndTime)
+}
+
+// StepStart starts a step and replies with its ids, so commands run in the
+// step can be its children.
+func (bs BgSpan) StepStart(in *BgStep, reply *BgSpan) error {
+	ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+
+	step, err := bs.steps.start(in.Name, in.Parent, in.Attributes, ts)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+	bs.fillReply(step, reply)
+	return nil
+}
+
+// StepEnd ends a step along with any steps open under it, and sends them
+// right away with --send-steps. Otherwise they're sent with the background
+// span.
+func (bs BgSpan) StepEnd(in *BgStepEnd, reply *BgSpan) error {
+	ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+
+	step, err := bs.steps.end(in.Step, ts, func(step *tracepb.Span) {
+		attrs := otlpclient.SpanAttributesToStringMap(step)
+		for k, v := range in.Attributes {
+			attrs[k] = v
+		}
+		step.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+		otlpclient.SetSpanStatus(step, in.StatusCode, in.StatusDesc)
+	})
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+	bs.fillReply(step, reply)
+
+	if bs.sendSteps != nil {
+		if err := bs.sendSteps(bs.steps.drain()); err != nil {
+			reply.Error = err.Error()
+			return err
+		}
+	}
+	return nil
+}
+
+// Info replies with the ids and name of the background span along with its
+// --bg-name, for span background list.
+func (bs BgSpan) Info(in *struct{}, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.fillReply(bs.span, reply)
+	reply.BgName = bs.config.BackgroundName
+	return nil
+}
+
+// Get replies with the span as it is right now, as JSON in the same format
+// otel-cli server json writes spans in.
+func (bs BgSpan) Get(in *struct{}, reply *json.RawMessage) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	js, err := json.Marshal(bs.span)
+	if err != nil {
+		return err
+	}
+	*reply = js
+	return nil
+}
+
+// SetAttributes sets attributes on the span, replacing any with the same key.
+func (bs BgSpan) SetAttributes(in *BgAttributes, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	attrs := otlpclient.SpanAttributesToStringMap(bs.span)
+	for k, v := range in.Attributes {
+		attrs[k] = v
+	}
+	bs.span.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// RemoveAttributes removes the attributes with the keys from the span. Keys
+// that aren't there are ignored.
+func (bs BgSpan) RemoveAttributes(in *BgAttributeKeys, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.span.Attributes = slices.DeleteFunc(bs.span.Attributes, func(kv *commonpb.KeyValue) bool {
+		return slices.Contains(in.Keys, kv.Key)
+	})
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// Rename changes the name of the span.
+func (bs BgSpan) Rename(in *BgRename, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if in.Name == "" {
+		reply.Error = "the span name can't be empty"
+		return errors.New(reply.Error)
+	}
+	bs.span.Name = in.Name
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// SetKind changes the kind of the span.
+func (bs BgSpan) SetKind(in *BgKind, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	kind := otlpclient.SpanKindStringToInt(in.Kind)
+	if kind == tracepb.Span_SPAN_KIND_UNSPECIFIED && in.Kind != "unspecified" {
+		reply.Error = fmt.Sprintf("invalid span kind %q", in.Kind)
+		return errors.New(reply.Error)
+	}
+	bs.span.Kind = kind
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// SetStatus changes the status of the span. Unlike --status-code on span
+// end, it can also set it back to unset.
+func (bs BgSpan) SetStatus(in *BgStatus, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	switch in.StatusCode {
+	case "unset":
+		bs.span.Status = &tracepb.Status{}
+	case "ok", "error":
+		otlpclient.SetSpanStatus(bs.span, in.StatusCode, in.StatusDesc)
+	default:
+		reply.Error = fmt.Sprintf("invalid status code %q, expected one of unset, ok, or error", in.StatusCode)
+		return errors.New(reply.Error)
+	}
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// SetStart moves the start time of the span, e.g. to backdate it to when
+// the work it covers really started.
+func (bs BgSpan) SetStart(in *BgTimestamp, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+	start := uint64(ts.UnixNano())
+	if bs.span.EndTimeUnixNano != 0 && start > bs.span.EndTimeUnixNano {
+		reply.Error = "the start time can't be after the end time"
+		return errors.New(reply.Error)
+	}
+	bs.span.StartTimeUnixNano = start
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// SetEnd sets the time the span will end at, without ending it. The span is
+// still sent when it's ended or the background process exits.
+func (bs BgSpan) SetEnd(in *BgTimestamp, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	}
+	end := uint64(ts.UnixNano())
+	if end < bs.span.StartTimeUnixNano {
+		reply.Error = "the end time can't be before the start time"
+		return errors.New(reply.Error)
+	}
+	bs.span.EndTimeUnixNano = end
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// AddLink links the span to another span, given as a traceparent.
+func (bs BgSpan) AddLink(in *BgLink, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	tp, err := traceparent.Parse(in.Traceparent)
+	if err != nil {
+		reply.Error = err.Error()
+		return err
+	} else if !tp.Initialized {
+		reply.Error = fmt.Sprintf("invalid traceparent %q for link", in.Traceparent)
+		return errors.New(reply.Error)
+	}
+
+	bs.span.Links = append(bs.span.Links, &tracepb.Span_Link{
+		TraceId:    tp.TraceId,
+		SpanId:     tp.SpanId,
+		Attributes: otlpclient.StringMapAttrsToProtobuf(in.Attributes),
+	})
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
+// Set makes all of the changes in a BgSet, or none of them if any one is
+// invalid, so span background set never leaves the span half changed.
+func (bs BgSpan) Set(in *BgSet, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// the setters make the changes to a copy, which replaces the span at the end
+	draft := bs
+	draft.span = proto.Clone(bs.span).(*tracepb.Span)
+	draft.mu = &sync.Mutex{}
+
+	// moving both times is checked here, since either one could be out of
+	// order with the other's old value
+	if in.Start != nil && in.End != nil {
+		draft.span.EndTimeUnixNano = 0
+	}
+
+	changes := []func() error{}
+	if in.Rename != nil {
+		changes = append(changes, func() error { return draft.Rename(in.Rename, reply) })
+	}
+	if in.Kind != nil {
+		changes = append(changes, func() error { return draft.SetKind(in.Kind, reply) })
+	}
+	if in.Start != nil {
+		changes = append(changes, func() error { return draft.SetStart(in.Start, reply) })
+	}
+	if in.End != nil {
+		changes = append(changes, func() error { return draft.SetEnd(in.End, reply) })
+	}
+	if in.RemoveAttrs != nil {
+		changes = append(changes, func() error { return draft.RemoveAttributes(in.RemoveAttrs, reply) })
+	}
+	if in.Attributes != nil {
+		changes = append(changes, func() error { return draft.SetAttributes(in.Attributes, reply) })
+	}
+	if in.Status != nil {
+		changes = append(changes, func() error { return draft.SetStatus(in.Status, reply) })
+	}
+	for i := range in.Links {
+		changes = append(changes, func() error { return draft.AddLink(&in.Links[i], reply) })
+	}
+
+	for _, change := range changes {
+		if err := change(); err != nil {
+			return err
+		}
+	}
+
+	proto.Reset(bs.span)
+	proto.Merge(bs.span, draft.span)
+	bs.fillReply(bs.span, reply)
+	return nil
+}
+
 // Wait is a no-op RPC for validating the background server is up and running.
 func (bs BgSpan) Wait(in, reply *struct{}) error {
 	return nil
@@ -71,6 +455,21 @@ func (bs BgSpan) Wait(in, reply *struct{}) error {
 // End takes a BgEnd (empty) struct, replies with the usual trace info, then
 // ends the span end exits the background process.
 func (bs BgSpan) End(in *BgEnd, reply *BgSpan) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// --end on span end wins over an end time set earlier with SetEnd
+	if in.Timestamp != "" {
+		ts, err := time.Parse(time.RFC3339Nano, in.Timestamp)
+		if err != nil {
+			reply.Error = err.Error()
+			return err
+		}
+		bs.span.EndTimeUnixNano = uint64(ts.UnixNano())
+	} else if bs.span.EndTimeUnixNano == 0 {
+		bs.span.EndTimeUnixNano = uint64(time.Now().UnixNano())
+	}
+
 	// handle --attrs arg to span end by retrieving and merging with/overwriting existing attribtues
 	attrs := make(map[string]string)
 	for k, v := range otlpclient.SpanAttributesToStringMap(bs.span) {
@@ -83,6 +482,7 @@ func (bs BgSpan) End(in *BgEnd, reply *BgSpan) error {
 	c := bs.config.WithStatusCode(in.StatusCode).WithStatusDescription(in.StatusDesc).WithAttributes(attrs)
 	otlpclient.SetSpanStatus(bs.span, c.StatusCode, c.StatusDescription)
 	bs.span.Attributes = otlpclient.StringMapAttrsToProtobuf(c.Attributes)
+	bs.fillReply(bs.span, reply)
 
 	// running the shutdown as a goroutine prevents the client from getting an
 	// error here when the server gets closed. defer didn't do the trick.
@@ -94,26 +494,32 @@ func (bs BgSpan) End(in *BgEnd, reply *BgSpan) error {
 type bgServer struct {
 	sockfile string
 	listener net.Listener
+	rpc      *rpc.Server
 	quit     chan struct{}
+	stopping sync.Once
+	mu       sync.Mutex // guards the span, see BgSpan
 	wg       sync.WaitGroup
 	config   Config
+	steps    *bgSteps
 }
 
 // createBgServer opens a new span background server on a unix socket and
-// returns with the server ready to go. Not expected to block.
-func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span) *bgServer {
+// returns with the server ready to go. Not expected to block. The client is
+// for sending steps as they end with --send-steps.
+func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span, client otlpclient.OTLPClient) *bgServer {
 	var err error
 	config := getConfig(ctx)
 
 	bgs := bgServer{
 		sockfile: sockfile,
+		rpc:      rpc.NewServer(),
 		quit:     make(chan struct{}),
 		config:   config,
+		steps:    newBgSteps(config, span),
 	}
 
-	// TODO: be safer?
-	if err = os.RemoveAll(sockfile); err != nil {
-		config.SoftFail("failed while cleaning up for socket file '%s': %s", sockfile, err)
+	if err = removeStaleBgSock(sockfile, config.GetTimeout()); err != nil {
+		config.SoftFail("%s", err)
 	}
 
 	bgspan := BgSpan{
@@ -121,10 +527,23 @@ func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span) *b
 		SpanID:   hex.EncodeToString(span.SpanId),
 		config:   config,
 		span:     span,
+		mu:       &bgs.mu,
+		steps:    bgs.steps,
 		shutdown: func() { bgs.Shutdown() },
 	}
-	// makes methods on BgSpan available over RPC
-	rpc.Register(&bgspan)
+	if config.BackgroundSendSteps {
+		bgspan.sendSteps = func(steps []*tracepb.Span) error {
+			ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+			defer cancel()
+			_, err := otlpclient.SendSpans(ctx, client, config, steps)
+			return err
+		}
+	}
+	// makes methods on BgSpan available over RPC, on a server of its own so
+	// one process can run more than one background span
+	if err = bgs.rpc.Register(&bgspan); err != nil {
+		config.SoftFail("failed to register background span rpc: %s", err)
+	}
 
 	bgs.listener, err = net.Listen("unix", sockfile)
 	if err != nil {
@@ -136,6 +555,23 @@ func createBgServer(ctx context.Context, sockfile string, span *tracepb.Span) *b
 	return &bgs
 }
 
+// removeStaleBgSock removes the socket left behind by a background span
+// that's gone, which refuses connections. One that answers belongs to a
+// background span that's still running, which is an error. Anything else
+// is left for net.Listen to complain about.
+func removeStaleBgSock(sockfile string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("unix", sockfile, timeout)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("a background span is already running on socket '%s', use another --bg-name or --sockdir", sockfile)
+	} else if errors.Is(err, syscall.ECONNREFUSED) {
+		if err = os.Remove(sockfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed while cleaning up for socket file '%s': %w", sockfile, err)
+		}
+	}
+	return nil
+}
+
 // Run will block until shutdown, accepting connections and processing them.
 func (bgs *bgServer) Run() {
 	// TODO: add controls to exit loop
@@ -144,6 +580,7 @@ func (bgs *bgServer) Run() {
 		if err != nil {
 			select {
 			case <-bgs.quit: // quitting gracefully
+				bgs.wg.Done() // the one added in createBgServer
 				return
 			default:
 				bgs.config.SoftFail("error while accepting connection: %s", err)
@@ -153,26 +590,42 @@ func (bgs *bgServer) Run() {
 		bgs.wg.Add(1)
 		go func() {
 			defer conn.Close()
-			jsonrpc.ServeConn(conn)
+			bgs.rpc.ServeCodec(jsonrpc.NewServerCodec(conn))
 			bgs.wg.Done()
 		}()
 	}
 }
 
 // Shutdown does a controlled shutdown of the background server. Blocks until
-// the server is turned down cleanly and it's safe to exit.
+// the server is turned down cleanly and it's safe to exit. Safe to call more
+// than once, e.g. from a signal and the timeout at the same time.
 func (bgs *bgServer) Shutdown() {
-	os.Remove(bgs.sockfile)
-	close(bgs.quit)
-	bgs.listener.Close()
+	bgs.stopping.Do(func() {
+		os.Remove(bgs.sockfile)
+		close(bgs.quit)
+		bgs.listener.Close()
+	})
 	bgs.wg.Wait()
 }
 
+// bgSockfile returns the path to the socket for the background span named by
+// --bg-name in --sockdir. Unnamed background spans use the original fixed
+// socket filename.
+func bgSockfile(config Config) string {
+	if config.BackgroundName == "" {
+		return path.Join(config.BackgroundSockdir, spanBgSockfilename)
+	}
+	if !bgNameRe.MatchString(config.BackgroundName) {
+		config.SoftFail("invalid --bg-name %q, it may only contain letters, numbers, '.', '_', and '-'", config.BackgroundName)
+	}
+	return path.Join(config.BackgroundSockdir, spanBgSockPrefix+config.BackgroundName+".sock")
+}
+
 // createBgClient sets up a client connection to the unix socket jsonrpc server
 // and returns the rpc client handle and a shutdown function that should be
 // deferred.
 func createBgClient(config Config) (*rpc.Client, func()) {
-	sockfile := path.Join(config.BackgroundSockdir, spanBgSockfilename)
+	sockfile := bgSockfile(config)
 	started := time.Now()
 	timeout := config.ParseCliTimeout()
 
@@ -192,10 +645,20 @@ func createBgClient(config Config) (*rpc.Client, func()) {
 		}
 	}
 
+	// the socket file can show up before the server is listening on it, or be
+	// left over from a previous server, so keep trying while it refuses connections
 	sock := net.UnixAddr{Name: sockfile, Net: "unix"}
-	conn, err := net.DialUnix(sock.Net, nil, &sock)
-	if err != nil {
-		config.SoftFail("unable to connect to span background server at '%s': %s", config.BackgroundSockdir, err)
+	var conn *net.UnixConn
+	for {
+		var err error
+		conn, err = net.DialUnix(sock.Net, nil, &sock)
+		if err == nil {
+			break
+		} else if !errors.Is(err, syscall.ECONNREFUSED) || (timeout > 0 && time.Since(started) > timeout) {
+			config.SoftFail("unable to connect to span background server at '%s': %s", config.BackgroundSockdir, err)
+			break
+		}
+		time.Sleep(time.Millisecond * 25)
 	}
 
 	return jsonrpc.NewClient(conn), func() { conn.Close() }
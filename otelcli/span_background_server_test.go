@@ -0,0 +1,160 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestBgSpanSetters(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	span := config.NewProtobufSpan()
+	span.EndTimeUnixNano = 0
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(map[string]string{"keep": "yes", "drop": "yes"})
+	bs := BgSpan{config: config, span: span, mu: &sync.Mutex{}}
+	reply := BgSpan{}
+
+	mustCall := func(name string, err error) {
+		if err != nil {
+			t.Fatalf("BgSpan.%s failed: %s", name, err)
+		}
+	}
+	mustCall("Rename", bs.Rename(&BgRename{Name: "renamed"}, &reply))
+	mustCall("SetKind", bs.SetKind(&BgKind{Kind: "server"}, &reply))
+	mustCall("SetAttributes", bs.SetAttributes(&BgAttributes{Attributes: map[string]string{"added": "yes"}}, &reply))
+	mustCall("RemoveAttributes", bs.RemoveAttributes(&BgAttributeKeys{Keys: []string{"drop", "missing"}}, &reply))
+	mustCall("SetStatus", bs.SetStatus(&BgStatus{StatusCode: "error", StatusDesc: "boom"}, &reply))
+	mustCall("AddLink", bs.AddLink(&BgLink{Traceparent: "00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01"}, &reply))
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	mustCall("SetStart", bs.SetStart(&BgTimestamp{Timestamp: start.Format(time.RFC3339Nano)}, &reply))
+	mustCall("SetEnd", bs.SetEnd(&BgTimestamp{Timestamp: start.Add(time.Second).Format(time.RFC3339Nano)}, &reply))
+
+	if span.Name != "renamed" || reply.Name != "renamed" {
+		t.Errorf("expected the span to be renamed, got %q", span.Name)
+	}
+	if span.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("expected kind server, got %s", span.Kind)
+	}
+	if attrs := flattenStringMap(otlpclient.SpanAttributesToStringMap(span), "{}"); attrs != "added=yes,keep=yes" {
+		t.Errorf("unexpected attributes %q", attrs)
+	}
+	if span.Status.Code != tracepb.Status_STATUS_CODE_ERROR || span.Status.Message != "boom" {
+		t.Errorf("unexpected status %v", span.Status)
+	}
+	if len(span.Links) != 1 || len(span.Links[0].SpanId) != 8 || span.Links[0].SpanId[0] != 0xaa {
+		t.Errorf("unexpected links %v", span.Links)
+	}
+	if reply.StartTime != uint64(start.UnixNano()) || reply.EndTime != uint64(start.Add(time.Second).UnixNano()) {
+		t.Errorf("unexpected start and end times %d, %d", reply.StartTime, reply.EndTime)
+	}
+
+	// SetStatus can go back to unset, which --status-code on span end can't
+	mustCall("SetStatus", bs.SetStatus(&BgStatus{StatusCode: "unset"}, &reply))
+	if span.Status.Code != tracepb.Status_STATUS_CODE_UNSET || span.Status.Message != "" {
+		t.Errorf("expected the status to be unset, got %v", span.Status)
+	}
+
+	var js json.RawMessage
+	mustCall("Get", bs.Get(&struct{}{}, &js))
+	got := struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.Unmarshal(js, &got); err != nil || got.Name != "renamed" {
+		t.Errorf("unexpected span json %s: %v", js, err)
+	}
+
+	for name, err := range map[string]error{
+		"Rename":    bs.Rename(&BgRename{}, &reply),
+		"SetKind":   bs.SetKind(&BgKind{Kind: "bogus"}, &reply),
+		"SetStatus": bs.SetStatus(&BgStatus{StatusCode: "bogus"}, &reply),
+		"SetStart":  bs.SetStart(&BgTimestamp{Timestamp: start.Add(time.Hour).Format(time.RFC3339Nano)}, &reply),
+		"SetEnd":    bs.SetEnd(&BgTimestamp{Timestamp: start.Add(-time.Hour).Format(time.RFC3339Nano)}, &reply),
+		"AddLink":   bs.AddLink(&BgLink{Traceparent: "nope"}, &reply),
+	} {
+		if err == nil {
+			t.Errorf("expected BgSpan.%s to fail", name)
+		}
+	}
+}
+
+func TestBgSpanSet(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	span := config.NewProtobufSpan()
+	span.Name = "original"
+	bs := BgSpan{config: config, span: span, mu: &sync.Mutex{}}
+	reply := BgSpan{}
+
+	// a bad change after a good one leaves the span as it was
+	err := bs.Set(&BgSet{
+		Rename:     &BgRename{Name: "renamed"},
+		Attributes: &BgAttributes{Attributes: map[string]string{"added": "yes"}},
+		Kind:       &BgKind{Kind: "bogus"},
+	}, &reply)
+	if err == nil || reply.Error == "" {
+		t.Errorf("expected Set with an invalid kind to fail")
+	}
+	if span.Name != "original" || len(span.Attributes) != 0 {
+		t.Errorf("expected the span to be unchanged, got name %q and attributes %v", span.Name, span.Attributes)
+	}
+
+	// both times can move past the other's old value in one Set
+	start := time.Unix(0, int64(span.EndTimeUnixNano)).Add(time.Hour)
+	reply = BgSpan{}
+	err = bs.Set(&BgSet{
+		Rename: &BgRename{Name: "renamed"},
+		Start:  &BgTimestamp{Timestamp: start.Format(time.RFC3339Nano)},
+		End:    &BgTimestamp{Timestamp: start.Add(time.Second).Format(time.RFC3339Nano)},
+		Links:  []BgLink{{Traceparent: "00-e39280f2980af3a8600ae98c74f2dabf-aaaaaaaaaaaaaaaa-01"}},
+	}, &reply)
+	if err != nil {
+		t.Fatalf("BgSpan.Set failed: %s", err)
+	}
+	if span.Name != "renamed" || reply.Name != "renamed" || len(span.Links) != 1 {
+		t.Errorf("expected the changes on the span, got name %q and links %v", span.Name, span.Links)
+	}
+	if span.StartTimeUnixNano != uint64(start.UnixNano()) || span.EndTimeUnixNano != uint64(start.Add(time.Second).UnixNano()) {
+		t.Errorf("unexpected start and end times %d, %d", span.StartTimeUnixNano, span.EndTimeUnixNano)
+	}
+}
+
+func TestBgSpanEndTime(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	ts := func(d time.Duration) string { return start.Add(d).Format(time.RFC3339Nano) }
+
+	for _, tc := range []struct {
+		name   string
+		setEnd string
+		endAt  string
+		want   uint64
+	}{
+		{"end time from span end --end", "", ts(2 * time.Second), uint64(start.Add(2 * time.Second).UnixNano())},
+		{"end time from set --end", ts(time.Second), "", uint64(start.Add(time.Second).UnixNano())},
+		{"span end --end wins", ts(time.Second), ts(3 * time.Second), uint64(start.Add(3 * time.Second).UnixNano())},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			span := config.NewProtobufSpan()
+			span.StartTimeUnixNano = uint64(start.UnixNano())
+			span.EndTimeUnixNano = 0
+			bs := BgSpan{config: config, span: span, mu: &sync.Mutex{}, shutdown: func() {}}
+			reply := BgSpan{}
+
+			if tc.setEnd != "" {
+				if err := bs.SetEnd(&BgTimestamp{Timestamp: tc.setEnd}, &reply); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := bs.End(&BgEnd{Timestamp: tc.endAt}, &reply); err != nil {
+				t.Fatal(err)
+			}
+			if span.EndTimeUnixNano != tc.want || reply.EndTime != tc.want {
+				t.Errorf("expected end time %d, got %d", tc.want, span.EndTimeUnixNano)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package otelcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// spanBgSetCmd represents the span background set command
+func spanBgSetCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "set",
+		Short: "change a running background span",
+		Long: `Change the name, kind, attributes, status, start or end time, or links of
+a running background span. Only the options given are changed.
+
+--end sets the time the span will end at without ending it, use span end
+to end it. --start can backdate the span to when the work really started.
+
+	otel-cli span background --sockdir $sd --name "deploy" &
+	otel-cli span background set --sockdir $sd \
+		--name "deploy $version" \
+		--attrs "deploy.version=$version" \
+		--remove-attr deploy.pending \
+		--link "$build_traceparent"
+`,
+		Run:  doSpanBgSet,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
+	cmd.Flags().StringVarP(&config.SpanName, "name", "n", defaults.SpanName, "rename the span")
+	cmd.Flags().StringVarP(&config.Kind, "kind", "k", defaults.Kind, "set the span kind, e.g. internal, server, client, producer, consumer")
+	cmd.Flags().StringVar(&config.SpanStartTime, "start", defaults.SpanStartTime, "a Unix epoch or RFC3339 timestamp to move the start of the span to")
+	cmd.Flags().StringVar(&config.SpanEndTime, "end", defaults.SpanEndTime, "a Unix epoch or RFC3339 timestamp for the span to end at when it ends")
+	cmd.Flags().StringArrayVar(&config.BackgroundRemoveAttrs, "remove-attr", defaults.BackgroundRemoveAttrs, "the key of an attribute to remove from the span, can be repeated")
+	cmd.Flags().StringArrayVar(&config.BackgroundLinks, "link", defaults.BackgroundLinks, "the traceparent of a span to link the span to, can be repeated")
+	cmd.Flags().BoolVar(&config.TraceparentPrint, "tp-print", defaults.TraceparentPrint, "print the trace id, span id, and the w3c-formatted traceparent representation of the span")
+	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "same as --tp-print but it puts an 'export ' in front so it's more convinenient to source in scripts")
+	addSpanStatusParams(&cmd, config)
+	addAttrParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSpanBgSet(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	flags := cmd.Flags()
+
+	client, shutdown := createBgClient(config)
+	defer shutdown()
+
+	// everything goes in one call, so either all of it is changed or none of it
+	set := BgSet{}
+	changed := false
+	if flags.Changed("name") {
+		set.Rename, changed = &BgRename{Name: config.SpanName}, true
+	}
+	if flags.Changed("kind") {
+		set.Kind, changed = &BgKind{Kind: config.Kind}, true
+	}
+	if flags.Changed("start") {
+		set.Start, changed = &BgTimestamp{Timestamp: config.ParseSpanStartTime().Format(time.RFC3339Nano)}, true
+	}
+	if flags.Changed("end") {
+		set.End, changed = &BgTimestamp{Timestamp: config.ParseSpanEndTime().Format(time.RFC3339Nano)}, true
+	}
+	if len(config.BackgroundRemoveAttrs) > 0 {
+		set.RemoveAttrs, changed = &BgAttributeKeys{Keys: config.BackgroundRemoveAttrs}, true
+	}
+	if len(config.Attributes) > 0 {
+		set.Attributes, changed = &BgAttributes{Attributes: config.Attributes}, true
+	}
+	if flags.Changed("status-code") {
+		set.Status, changed = &BgStatus{StatusCode: config.StatusCode, StatusDesc: config.StatusDescription}, true
+	}
+	for _, link := range config.BackgroundLinks {
+		set.Links, changed = append(set.Links, BgLink{Traceparent: link}), true
+	}
+
+	// nothing to change is fine, print the span info like the rest do
+	method, rpcArgs := "Set", any(&set)
+	if !changed {
+		method, rpcArgs = "Info", &struct{}{}
+	}
+
+	res := BgSpan{}
+	err := client.Call("BgSpan."+method, rpcArgs, &res)
+	if err != nil {
+		config.SoftFail("error while calling background server rpc BgSpan.%s: %s", method, err)
+	}
+
+	config.printSpanOutput(res.output(cmd.Context(), config), os.Stdout)
+}
+
+// spanBgGetCmd represents the span background get command
+func spanBgGetCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "get",
+		Short: "print a running background span as JSON",
+		Long: `Print a running background span as it is right now, as JSON in the same
+format otel-cli server json writes spans in.
+
+	otel-cli span background get --sockdir $sd | jq .attributes
+`,
+		Run:  doSpanBgGet,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
+
+	return &cmd
+}
+
+func doSpanBgGet(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	client, shutdown := createBgClient(config)
+	defer shutdown()
+
+	var res json.RawMessage
+	err := client.Call("BgSpan.Get", &struct{}{}, &res)
+	if err != nil {
+		config.SoftFail("error while calling background server rpc BgSpan.Get: %s", err)
+	}
+
+	fmt.Println(string(res))
+}
@@ -0,0 +1,277 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tobert/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// bgSteps tracks the child spans, or steps, of a background span. Steps are
+// started and ended over RPC from any number of connections, so everything
+// goes through the lock.
+type bgSteps struct {
+	mu        sync.Mutex
+	root      *tracepb.Span
+	recording bool
+	open      []*tracepb.Span // in the order they were started
+	parents   map[*tracepb.Span]*tracepb.Span
+	ended     []*tracepb.Span // waiting to be sent
+}
+
+func newBgSteps(config Config, root *tracepb.Span) *bgSteps {
+	return &bgSteps{
+		root:      root,
+		recording: config.GetIsRecording(),
+		parents:   map[*tracepb.Span]*tracepb.Span{},
+	}
+}
+
+// find returns the index of the newest open step with the span id or name,
+// or the newest open step when which is empty. Returns -1 if there isn't one.
+func (s *bgSteps) find(which string) int {
+	for i := len(s.open) - 1; i >= 0; i-- {
+		if which == "" || which == s.open[i].Name || which == hex.EncodeToString(s.open[i].SpanId) {
+			return i
+		}
+	}
+	return -1
+}
+
+// start opens a step under the parent, which is the span id of the
+// background span or the span id or name of an open step. Without a parent
+// it goes under the newest open step, or the background span when there
+// are none, so steps nest the way they're started.
+func (s *bgSteps) start(name, parent string, attrs map[string]string, ts time.Time) (*tracepb.Span, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parentSpan := s.root
+	if parent != hex.EncodeToString(s.root.SpanId) {
+		if i := s.find(parent); i >= 0 {
+			parentSpan = s.open[i]
+		} else if parent != "" {
+			return nil, fmt.Errorf("no open step or background span %q to start a step under", parent)
+		}
+	}
+
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = s.root.TraceId
+	span.ParentSpanId = parentSpan.SpanId
+	if s.recording {
+		span.SpanId = otlpclient.GenerateSpanId()
+	}
+	span.Name = name
+	span.Kind = tracepb.Span_SPAN_KIND_INTERNAL
+	span.StartTimeUnixNano = uint64(ts.UnixNano())
+	span.EndTimeUnixNano = 0
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+
+	s.open = append(s.open, span)
+	s.parents[span] = parentSpan
+	return span, nil
+}
+
+// end ends the open step with the span id or name, or the newest open step
+// when which is empty, along with any open steps under it. update is called
+// with the step before it ends, under the lock, to set attributes and status.
+func (s *bgSteps) end(which string, ts time.Time, update func(*tracepb.Span)) (*tracepb.Span, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(which)
+	if i < 0 {
+		if which == "" {
+			return nil, fmt.Errorf("there are no open steps to end")
+		}
+		return nil, fmt.Errorf("no open step %q to end", which)
+	}
+	step := s.open[i]
+	update(step)
+
+	// steps under this one end with it, children before their parents
+	for j := len(s.open) - 1; j > i; j-- {
+		if open := s.open[j]; s.descends(open, step) {
+			open.EndTimeUnixNano = uint64(ts.UnixNano())
+			s.ended = append(s.ended, open)
+		}
+	}
+	step.EndTimeUnixNano = uint64(ts.UnixNano())
+	s.ended = append(s.ended, step)
+
+	s.open = slices.DeleteFunc(s.open, func(span *tracepb.Span) bool {
+		return span.EndTimeUnixNano != 0
+	})
+	return step, nil
+}
+
+// descends returns true when span was started under ancestor, directly or
+// not. Only called with the lock held.
+func (s *bgSteps) descends(span, ancestor *tracepb.Span) bool {
+	for parent := s.parents[span]; parent != nil; parent = s.parents[parent] {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// drain returns the steps that have ended since the last drain.
+func (s *bgSteps) drain() []*tracepb.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ended := s.ended
+	s.ended = nil
+	return ended
+}
+
+// finish ends the steps that are still open, marking them with a
+// background.step.unterminated attribute, and returns every step that
+// hasn't been sent yet.
+func (s *bgSteps) finish(ts time.Time) []*tracepb.Span {
+	s.mu.Lock()
+	for j := len(s.open) - 1; j >= 0; j-- {
+		open := s.open[j]
+		open.Attributes = append(open.Attributes, boolAttr("background.step.unterminated", true))
+		open.EndTimeUnixNano = uint64(ts.UnixNano())
+		s.ended = append(s.ended, open)
+	}
+	s.open = nil
+	s.mu.Unlock()
+
+	return s.drain()
+}
+
+// spanBgStepCmd represents the span background step command
+func spanBgStepCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "step",
+		Short: "start and end child spans of a background span",
+		Long: `Steps are child spans of a background span, started and ended from the
+script that runs it. Steps nest in the order they're started unless
+--parent says otherwise, and the traceparent of each step is printed like
+otel-cli span does, so commands in the step can be its children.
+
+	otel-cli span background --sockdir $sd &
+	otel-cli span background step start --sockdir $sd --name build
+	otel-cli span background step start --sockdir $sd --name compile
+	make
+	otel-cli span background step end --sockdir $sd   # compile
+	otel-cli span background step end --sockdir $sd --step build
+`,
+	}
+
+	cmd.AddCommand(spanBgStepStartCmd(config))
+	cmd.AddCommand(spanBgStepEndCmd(config))
+
+	return &cmd
+}
+
+// spanBgStepStartCmd represents the span background step start command
+func spanBgStepStartCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "start",
+		Short: "start a step in a background span",
+		Run:   doSpanBgStepStart,
+		Args:  cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
+	cmd.Flags().StringVarP(&config.SpanName, "name", "n", defaults.SpanName, "set the name of the step")
+	cmd.Flags().StringVar(&config.BackgroundStepParent, "parent", defaults.BackgroundStepParent, "the name or span id of the open step, or the span id of the background span, to start this one under")
+	cmd.Flags().StringVar(&config.SpanStartTime, "start", defaults.SpanStartTime, "a Unix epoch or RFC3339 timestamp for the start of the step")
+	cmd.Flags().BoolVar(&config.TraceparentPrint, "tp-print", defaults.TraceparentPrint, "print the trace id, span id, and the w3c-formatted traceparent representation of the step")
+	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "same as --tp-print but it puts an 'export ' in front so it's more convinenient to source in scripts")
+	addAttrParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSpanBgStepStart(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	start := time.Now()
+	if config.SpanStartTime != "" {
+		start = config.ParseSpanStartTime()
+	}
+	rpcArgs := BgStep{
+		Name:       config.GetSpanName(parentCommandLine()),
+		Parent:     config.BackgroundStepParent,
+		Timestamp:  start.Format(time.RFC3339Nano),
+		Attributes: config.Attributes,
+	}
+
+	res := BgSpan{}
+	client, shutdown := createBgClient(config)
+	defer shutdown()
+	err := client.Call("BgSpan.StepStart", rpcArgs, &res)
+	if err != nil {
+		config.SoftFail("error while calling background server rpc BgSpan.StepStart: %s", err)
+	}
+
+	config.printSpanOutput(res.output(cmd.Context(), config), os.Stdout)
+}
+
+// spanBgStepEndCmd represents the span background step end command
+func spanBgStepEndCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "end",
+		Short: "end a step in a background span",
+		Long: `End the newest open step, or the one named by --step, along with any
+steps still open under it.`,
+		Run:  doSpanBgStepEnd,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVar(&config.Verbose, "verbose", defaults.Verbose, "print errors on failure instead of always being silent")
+	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
+	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
+	cmd.Flags().StringVar(&config.BackgroundStep, "step", defaults.BackgroundStep, "the name or span id of the open step to end, default is the newest one")
+	cmd.Flags().StringVar(&config.SpanEndTime, "end", defaults.SpanEndTime, "an Unix epoch or RFC3339 timestamp for the end of the step")
+	cmd.Flags().BoolVar(&config.TraceparentPrint, "tp-print", defaults.TraceparentPrint, "print the trace id, span id, and the w3c-formatted traceparent representation of the step")
+	cmd.Flags().BoolVarP(&config.TraceparentPrintExport, "tp-export", "p", defaults.TraceparentPrintExport, "same as --tp-print but it puts an 'export ' in front so it's more convinenient to source in scripts")
+	addSpanStatusParams(&cmd, config)
+	addAttrParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSpanBgStepEnd(cmd *cobra.Command, args []string) {
+	config := getConfig(cmd.Context())
+	end := time.Now()
+	if config.SpanEndTime != "" {
+		end = config.ParseSpanEndTime()
+	}
+	rpcArgs := BgStepEnd{
+		Step:       config.BackgroundStep,
+		Timestamp:  end.Format(time.RFC3339Nano),
+		Attributes: config.Attributes,
+		StatusCode: config.StatusCode,
+		StatusDesc: config.StatusDescription,
+	}
+
+	res := BgSpan{}
+	client, shutdown := createBgClient(config)
+	defer shutdown()
+	err := client.Call("BgSpan.StepEnd", rpcArgs, &res)
+	if err != nil {
+		config.SoftFail("error while calling background server rpc BgSpan.StepEnd: %s", err)
+	}
+
+	config.printSpanOutput(res.output(cmd.Context(), config), os.Stdout)
+}
@@ -0,0 +1,71 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestBgSteps(t *testing.T) {
+	config := DefaultConfig().WithEndpoint("localhost:4317")
+	root := config.NewProtobufSpan()
+	steps := newBgSteps(config, root)
+	ts := time.Now()
+
+	mustStart := func(name, parent string) *tracepb.Span {
+		span, err := steps.start(name, parent, nil, ts)
+		if err != nil {
+			t.Fatalf("starting step %q failed: %s", name, err)
+		}
+		return span
+	}
+
+	build := mustStart("build", "")
+	compile := mustStart("compile", "")                            // nests under build
+	lint := mustStart("lint", "build")                             // also under build
+	deploy := mustStart("deploy", hex.EncodeToString(root.SpanId)) // back at the top
+	for _, tc := range []struct {
+		step   *tracepb.Span
+		parent *tracepb.Span
+	}{{build, root}, {compile, build}, {lint, build}, {deploy, root}} {
+		if string(tc.step.ParentSpanId) != string(tc.parent.SpanId) || string(tc.step.TraceId) != string(root.TraceId) {
+			t.Errorf("step %q should be a child of %q", tc.step.Name, tc.parent.Name)
+		}
+	}
+
+	if _, err := steps.start("nope", "missing", nil, ts); err == nil {
+		t.Error("expected an error starting a step under a missing parent")
+	}
+
+	// ending build ends compile and lint with it, but not deploy
+	ended, err := steps.end("build", ts, func(step *tracepb.Span) {
+		otlpclient.SetSpanStatus(step, "error", "failed")
+	})
+	if err != nil || ended != build || build.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Fatalf("ending build failed: %v, %s", ended, err)
+	}
+	if got := steps.drain(); len(got) != 3 || got[2] != build {
+		t.Errorf("expected 3 ended steps with build last, got %d", len(got))
+	}
+	if len(steps.open) != 1 || steps.open[0] != deploy {
+		t.Errorf("expected only deploy to still be open, got %d", len(steps.open))
+	}
+
+	if _, err := steps.end("build", ts, func(*tracepb.Span) {}); err == nil {
+		t.Error("expected an error ending a step that already ended")
+	}
+
+	remaining := steps.finish(ts)
+	if len(remaining) != 1 || remaining[0] != deploy || deploy.EndTimeUnixNano == 0 {
+		t.Fatalf("expected deploy to end with finish, got %d steps", len(remaining))
+	}
+	if otlpclient.SpanAttributesToStringMap(deploy)["background.step.unterminated"] != "true" {
+		t.Error("expected the open step to be marked unterminated")
+	}
+	if _, err := steps.end("", ts, func(*tracepb.Span) {}); err == nil {
+		t.Error("expected an error ending a step when none are open")
+	}
+}
@@ -2,9 +2,9 @@ package otelcli
 
 import (
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/tobert/otel-cli/w3c/traceparent"
 )
 
 // spanEndCmd represents the span event command
@@ -29,6 +29,7 @@ See: otel-cli span background
 	//cmd.Flags().StringVar(&config.Timeout, "timeout", defaults.Timeout, "timeout for otel-cli operations, all timeouts in otel-cli use this value")
 	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", defaults.BackgroundSockdir, "a directory where a socket can be placed safely")
 	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
 
 	cmd.Flags().StringVar(&config.SpanEndTime, "end", defaults.SpanEndTime, "an Unix epoch or RFC3339 timestamp for the end of the span")
 
@@ -47,6 +48,10 @@ func doSpanEnd(cmd *cobra.Command, args []string) {
 		StatusCode: config.StatusCode,
 		StatusDesc: config.StatusDescription,
 	}
+	// without --end the span ends now, or when span background set --end said
+	if cmd.Flags().Changed("end") {
+		rpcArgs.Timestamp = config.ParseSpanEndTime().Format(time.RFC3339Nano)
+	}
 
 	res := BgSpan{}
 	err := client.Call("BgSpan.End", rpcArgs, &res)
@@ -55,8 +60,5 @@ func doSpanEnd(cmd *cobra.Command, args []string) {
 	}
 	shutdown()
 
-	tp, _ := traceparent.Parse(res.Traceparent)
-	if config.TraceparentPrint {
-		tp.Fprint(os.Stdout, config.TraceparentPrintExport)
-	}
+	config.printSpanOutput(res.output(cmd.Context(), config), os.Stdout)
 }
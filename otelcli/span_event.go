@@ -1,11 +1,12 @@
 package otelcli
 
 import (
+	"context"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/tobert/otel-cli/w3c/traceparent"
+	"github.com/tobert/otel-cli/otlpclient"
 )
 
 // spanEventCmd represents the span event command
@@ -17,6 +18,9 @@ func spanEventCmd(config *Config) *cobra.Command {
 
 See: otel-cli span background
 
+With --state instead of --sockdir, the event is added to the innermost span
+saved by otel-cli span start and is sent by otel-cli span finish.
+
     sd=$(mktemp -d)
 	otel-cli span background --sockdir $sd
 	otel-cli span event \
@@ -38,7 +42,8 @@ See: otel-cli span background
 	cmd.Flags().StringVarP(&config.EventName, "name", "e", defaults.EventName, "set the name of the event")
 	cmd.Flags().StringVarP(&config.EventTime, "time", "t", defaults.EventTime, "the precise time of the event in RFC3339Nano or Unix.nano format")
 	cmd.Flags().StringVar(&config.BackgroundSockdir, "sockdir", "", "a directory where a socket can be placed safely")
-	cmd.MarkFlagRequired("sockdir")
+	cmd.Flags().StringVar(&config.BackgroundName, "bg-name", defaults.BackgroundName, "the name of the background span, set with span background --bg-name")
+	addSpanStateParams(&cmd, config)
 
 	addAttrParams(&cmd, config)
 
@@ -49,11 +54,20 @@ func doSpanEvent(cmd *cobra.Command, args []string) {
 	config := getConfig(cmd.Context())
 	timestamp := config.ParsedEventTime()
 	rpcArgs := BgSpanEvent{
-		Name:       config.EventName,
+		Name:       config.GetEventName(),
 		Timestamp:  timestamp.Format(time.RFC3339Nano),
 		Attributes: config.Attributes,
 	}
 
+	// --sockdir wins, OTEL_CLI_SPAN_STATE may well be set for a whole script
+	if config.BackgroundSockdir == "" {
+		if config.SpanState == "" {
+			config.SoftFail("one of --sockdir or --state is required")
+		}
+		addSpanStateEvent(cmd.Context(), config, rpcArgs)
+		return
+	}
+
 	res := BgSpan{}
 	client, shutdown := createBgClient(config)
 	defer shutdown()
@@ -62,11 +76,33 @@ func doSpanEvent(cmd *cobra.Command, args []string) {
 		config.SoftFail("error while calling background server rpc BgSpan.AddEvent: %s", err)
 	}
 
-	if config.TraceparentPrint {
-		tp, err := traceparent.Parse(res.Traceparent)
-		if err != nil {
-			config.SoftFail("Could not parse traceparent: %s", err)
-		}
-		tp.Fprint(os.Stdout, config.TraceparentPrintExport)
+	config.printSpanOutput(res.output(cmd.Context(), config), os.Stdout)
+}
+
+// addSpanStateEvent adds the event to the innermost span pending in
+// --state, to be sent along with it by span finish.
+func addSpanStateEvent(ctx context.Context, config Config, bse BgSpanEvent) {
+	unlock, err := lockSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+	defer unlock()
+
+	states, err := loadSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+	if len(states) == 0 {
+		config.SoftFail("no pending span to add an event to in %q, run otel-cli span start first", config.SpanState)
 	}
+
+	state := &states[len(states)-1]
+	state.Events = append(state.Events, spanStateEvent{
+		Name:       bse.Name,
+		Time:       bse.Timestamp,
+		Attributes: bse.Attributes,
+	})
+	err = saveSpanStates(config.SpanState, states)
+	config.SoftFailIfErr(err)
+
+	span, err := state.span()
+	config.SoftFailIfErr(err)
+	tp := otlpclient.TraceparentFromProtobufSpan(span, config.GetIsRecording())
+	config.printSpanOutput(newSpanOutput(ctx, config, tp, span.ParentSpanId, span.StartTimeUnixNano, 0), os.Stdout)
 }
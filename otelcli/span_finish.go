@@ -0,0 +1,101 @@
+package otelcli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tobert/otel-cli/otlpclient"
+)
+
+// spanFinishCmd represents the span finish command
+func spanFinishCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "finish",
+		Short: "finish the span started last by span start and send it",
+		Long: `Finish the innermost pending span saved by otel-cli span start, adding
+attributes, events, and status to it, and send it. The span's parent, if it
+was started inside another pending span, becomes the innermost one again.
+The endpoint and other client settings come from span finish, the service
+name comes from span start unless --service is set here.
+
+	otel-cli span finish --state $state \
+		--event "cache miss" \
+		--status-code error --status-description "tests failed" \
+		--attrs "tests.failed=3"
+`,
+		Run:  doSpanFinish,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	cmd.Flags().StringVarP(&config.ServiceName, "service", "s", defaults.ServiceName, "set the name of the application sent on the traces")
+	cmd.Flags().StringVar(&config.SpanEndTime, "end", defaults.SpanEndTime, "an Unix epoch or RFC3339 timestamp for the end of the span")
+	cmd.Flags().StringArrayVar(&config.SpanFinishEvents, "event", defaults.SpanFinishEvents, "add an event with this name at the end of the span, can be repeated")
+	addSpanStateParams(&cmd, config)
+	addSpanStatusParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+func doSpanFinish(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	unlock, err := lockSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+
+	states, err := loadSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+	if len(states) == 0 {
+		config.SoftFail("no pending span to finish in %q, run otel-cli span start first", config.SpanState)
+	}
+
+	// pop before sending, so a failed send doesn't leave the span behind
+	// to become the parent of whatever's started next
+	state := states[len(states)-1]
+	err = saveSpanStates(config.SpanState, states[:len(states)-1])
+	config.SoftFailIfErr(err)
+	unlock() // no need to hold it while sending
+
+	span, err := state.span()
+	config.SoftFailIfErr(err)
+
+	end := time.Now()
+	if config.SpanEndTime != "" {
+		end = config.ParseSpanEndTime()
+	}
+	span.EndTimeUnixNano = uint64(end.UnixNano())
+
+	attrs := otlpclient.SpanAttributesToStringMap(span)
+	for k, v := range config.Attributes {
+		attrs[k] = v
+	}
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(attrs)
+
+	for _, name := range config.SpanFinishEvents {
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = name
+		event.TimeUnixNano = span.EndTimeUnixNano
+		span.Events = append(span.Events, event)
+	}
+	otlpclient.SetSpanStatus(span, config.StatusCode, config.StatusDescription)
+
+	if !cmd.Flags().Changed("service") && state.ServiceName != "" {
+		config.ServiceName = state.ServiceName
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(config.GetTimeout()))
+	defer cancel()
+	ctx, client := StartClient(ctx, config)
+	ctx, err = otlpclient.SendSpan(ctx, client, config, span)
+	config.failExportIfErr(err)
+	ctx, err = client.Stop(ctx)
+	config.failExportIfErr(err)
+	config.PropagateTraceparent(ctx, span, os.Stdout)
+}
@@ -0,0 +1,125 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// spanNameMaxArgs and spanNameMaxArgLen keep generated span names short
+// enough to read in a trace view, and low enough in cardinality to group.
+const (
+	spanNameMaxArgs   = 2
+	spanNameMaxArgLen = 40
+)
+
+// spanNameInterpreters are commands that run a script, so the script makes
+// a better name than they do, e.g. deploy.sh instead of bash.
+var spanNameInterpreters = []string{
+	"sh", "bash", "dash", "zsh", "ksh", "fish",
+	"python", "python3", "node", "ruby", "perl", "php",
+}
+
+// GetSpanName returns --name when it's set, otherwise --name-template
+// expanded with args, or failing that a name made from args, which is the
+// command line of whatever the span is about: the command for exec, the
+// parent process for most everything else.
+func (c Config) GetSpanName(args []string) string {
+	if c.SpanName != "" {
+		return c.SpanName
+	} else if c.NameTemplate != "" {
+		return expandPlaceholders(c.NameTemplate, nameTemplateLookup(args))
+	}
+	return defaultSpanName(args)
+}
+
+// GetEventName returns --name for span event when it's set, otherwise a
+// name made from the command line of the process adding the event.
+func (c Config) GetEventName() string {
+	if c.EventName != "" {
+		return c.EventName
+	}
+	return defaultSpanName(parentCommandLine())
+}
+
+// defaultSpanName makes a name from a command line: the command's base
+// name and its first few arguments that aren't options, e.g. "make test"
+// for /usr/bin/make -j8 test. When the command is a shell or interpreter
+// running a script, the script takes its place.
+func defaultSpanName(args []string) string {
+	if len(args) == 0 {
+		return "otel-cli"
+	}
+
+	// login shells show up as e.g. -bash
+	command := strings.TrimPrefix(filepath.Base(args[0]), "-")
+	rest := args[1:]
+	if slices.Contains(spanNameInterpreters, command) && len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		command, rest = filepath.Base(rest[0]), rest[1:]
+	}
+
+	name := []string{command}
+	for _, arg := range rest {
+		if len(name) > spanNameMaxArgs {
+			break
+		} else if strings.HasPrefix(arg, "-") || arg == "" {
+			continue
+		} else if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			continue // usually an option's value, e.g. -j 8
+		}
+
+		arg = strings.Join(strings.Fields(arg), " ")
+		if len(arg) > spanNameMaxArgLen {
+			arg = strings.TrimSpace(strings.ToValidUTF8(arg[:spanNameMaxArgLen], "")) + "..."
+		}
+		name = append(name, arg)
+	}
+
+	return strings.Join(name, " ")
+}
+
+// nameTemplateLookup returns the placeholders for --name-template:
+//
+//	{{name}}      the name otel-cli would have made up
+//	{{command}}   the base name of the command
+//	{{args}}      the whole command line
+//	{{arg:N}}     one argument, with 0 being the command
+//	{{env:NAME}}  an environment variable
+//	{{cwd}}       the working directory
+//	{{dir}}       the base name of the working directory
+func nameTemplateLookup(args []string) func(string) (string, bool) {
+	return func(placeholder string) (string, bool) {
+		name, arg, _ := strings.Cut(placeholder, ":")
+		switch name {
+		case "name":
+			return defaultSpanName(args), true
+		case "command":
+			if len(args) > 0 {
+				return filepath.Base(args[0]), true
+			}
+			return "", true
+		case "args":
+			return strings.Join(args, " "), true
+		case "arg":
+			if i, err := strconv.Atoi(arg); err == nil && i >= 0 {
+				if i < len(args) {
+					return args[i], true
+				}
+				return "", true
+			}
+		case "env":
+			return os.Getenv(arg), true
+		case "cwd", "dir":
+			cwd, err := os.Getwd()
+			if err != nil {
+				return "", true
+			} else if name == "dir" {
+				return filepath.Base(cwd), true
+			}
+			return cwd, true
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,53 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultSpanName(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "otel-cli"},
+		{[]string{"/usr/bin/make", "-j", "8", "test"}, "make test"},
+		{[]string{"curl", "-sS", "https://example.com/api", "-o", "out.json", "extra"}, "curl https://example.com/api out.json"},
+		{[]string{"/bin/bash", "./scripts/deploy.sh", "prod"}, "deploy.sh prod"},
+		{[]string{"-bash"}, "bash"},
+		{[]string{"sh", "-c", "echo hi"}, "sh echo hi"},
+		{[]string{"python3", "-m", "http.server", "8080"}, "python3 http.server"},
+		{[]string{"echo", "this argument is much longer than forty characters for sure"}, "echo this argument is much longer than forty..."},
+		{[]string{"echo", "multi\nline   arg"}, "echo multi line arg"},
+	} {
+		if got := defaultSpanName(tc.args); got != tc.want {
+			t.Errorf("defaultSpanName(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestGetSpanName(t *testing.T) {
+	t.Setenv("OTEL_CLI_TEST_JOB", "nightly")
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"/usr/bin/make", "-j", "8", "test"}
+	for _, tc := range []struct {
+		config Config
+		want   string
+	}{
+		{DefaultConfig(), "make test"},
+		{DefaultConfig().WithSpanName("build").WithNameTemplate("{{command}}"), "build"},
+		{DefaultConfig().WithNameTemplate("{{command}} in {{dir}}"), "make in " + filepath.Base(cwd)},
+		{DefaultConfig().WithNameTemplate("{{env:OTEL_CLI_TEST_JOB}}: {{name}}"), "nightly: make test"},
+		{DefaultConfig().WithNameTemplate("{{arg:3}} {{arg:9}}{{args}}"), "test /usr/bin/make -j 8 test"},
+		{DefaultConfig().WithNameTemplate("{{cwd}} {{unknown}}"), cwd + " {{unknown}}"},
+	} {
+		if got := tc.config.GetSpanName(args); got != tc.want {
+			t.Errorf("GetSpanName with template %q = %q, want %q", tc.config.NameTemplate, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package otelcli
+
+import (
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// spanStack tracks nested child spans of a root span as they're started and
+// ended by something other than otel-cli, e.g. lines of input in otel-cli
+// pipe or records from an exec'd command.
+type spanStack struct {
+	root      *tracev1.Span
+	recording bool
+	open      []stackedSpan   // innermost span last
+	ended     []*tracev1.Span // child spans ready to send
+}
+
+// stackedSpan is an open span and an optional tag for finding it again.
+type stackedSpan struct {
+	span *tracev1.Span
+	tag  int
+}
+
+func newSpanStack(config Config, root *tracev1.Span) *spanStack {
+	return &spanStack{root: root, recording: config.GetIsRecording()}
+}
+
+// current returns the innermost open span, or the root span.
+func (ss *spanStack) current() *tracev1.Span {
+	if len(ss.open) > 0 {
+		return ss.open[len(ss.open)-1].span
+	}
+	return ss.root
+}
+
+// start opens a new internal span as a child of the current span.
+func (ss *spanStack) start(name string, tag int, ts time.Time) *tracev1.Span {
+	span := otlpclient.NewProtobufSpan()
+	span.TraceId = ss.root.TraceId
+	span.ParentSpanId = ss.current().SpanId
+	if ss.recording {
+		span.SpanId = otlpclient.GenerateSpanId()
+	}
+	span.Name = name
+	span.Kind = tracev1.Span_SPAN_KIND_INTERNAL
+	span.StartTimeUnixNano = uint64(ts.UnixNano())
+
+	ss.open = append(ss.open, stackedSpan{span: span, tag: tag})
+	return span
+}
+
+// end ends the innermost open span that match accepts, along with any spans
+// opened inside of it, and returns it. Returns nil when nothing matched.
+func (ss *spanStack) end(match func(stackedSpan) bool, ts time.Time) *tracev1.Span {
+	for i := len(ss.open) - 1; i >= 0; i-- {
+		if match(ss.open[i]) {
+			span := ss.open[i].span
+			ss.closeFrom(i, ts)
+			return span
+		}
+	}
+	return nil
+}
+
+// closeFrom ends the open span at index i and every span opened after it.
+func (ss *spanStack) closeFrom(i int, ts time.Time) {
+	for j := len(ss.open) - 1; j >= i; j-- {
+		ss.open[j].span.EndTimeUnixNano = uint64(ts.UnixNano())
+		ss.ended = append(ss.ended, ss.open[j].span)
+	}
+	ss.open = ss.open[:i]
+}
+
+// finish ends any spans that are still open, marking them with a
+// <prefix>.span.unterminated attribute, and returns all of the child spans.
+// The root span is left alone.
+func (ss *spanStack) finish(prefix string, ts time.Time) []*tracev1.Span {
+	for _, open := range ss.open {
+		open.span.Attributes = append(open.span.Attributes, boolAttr(prefix+".span.unterminated", true))
+	}
+	ss.closeFrom(0, ts)
+	return ss.ended
+}
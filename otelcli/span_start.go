@@ -0,0 +1,78 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// spanStartCmd represents the span start command
+func spanStartCmd(config *Config) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "start",
+		Short: "start a span and save it to a state file for span finish",
+		Long: `Start a span without keeping a process around: its ids, name, start
+time, and attributes are saved to a state file or directory and nothing is
+sent until otel-cli span finish. Spans started while another one is pending
+in the same state are its children, so start and finish nest like push and
+pop. The traceparent is printed and propagated like otel-cli span does.
+
+	export OTEL_CLI_SPAN_STATE=$(mktemp -d)
+	otel-cli span start --name build
+	otel-cli span start --name test
+	make test
+	otel-cli span finish --attrs "tests.passed=true"
+	otel-cli span finish
+`,
+		Run:  doSpanStart,
+		Args: cobra.NoArgs,
+	}
+
+	defaults := DefaultConfig()
+	cmd.Flags().SortFlags = false
+
+	addCommonParams(&cmd, config)
+	addSpanParams(&cmd, config)
+	cmd.Flags().StringVar(&config.SpanStartTime, "start", defaults.SpanStartTime, "a Unix epoch or RFC3339 timestamp for the start of the span")
+	addSpanStateParams(&cmd, config)
+	addAttrParams(&cmd, config)
+	addClientParams(&cmd, config)
+
+	return &cmd
+}
+
+// addSpanStateParams adds --state to span start, finish, and event.
+func addSpanStateParams(cmd *cobra.Command, config *Config) {
+	defaults := DefaultConfig()
+	cmd.Flags().StringVar(&config.SpanState, "state", defaults.SpanState, "a file, or a directory to keep a file per span in, where span start saves pending spans for span finish")
+}
+
+func doSpanStart(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	config := getConfig(ctx)
+	unlock, err := lockSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+	defer unlock()
+
+	states, err := loadSpanStates(config.SpanState)
+	config.SoftFailIfErr(err)
+
+	span := config.NewProtobufSpan()
+	span.EndTimeUnixNano = 0 // set by span finish
+
+	// the innermost pending span is the parent, over any TRACEPARENT
+	if len(states) > 0 && config.GetIsRecording() {
+		parent := states[len(states)-1]
+		span.TraceId, err = hex.DecodeString(parent.TraceId)
+		config.SoftFailIfErr(err)
+		span.ParentSpanId, err = hex.DecodeString(parent.SpanId)
+		config.SoftFailIfErr(err)
+	}
+
+	states = append(states, newSpanState(config, span))
+	err = saveSpanStates(config.SpanState, states)
+	config.SoftFailIfErr(err)
+
+	config.PropagateTraceparent(ctx, span, os.Stdout)
+}
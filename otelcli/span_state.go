@@ -0,0 +1,242 @@
+package otelcli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tobert/otel-cli/otlpclient"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// spanState is a span that was started by otel-cli span start and is
+// waiting on disk for otel-cli span finish.
+type spanState struct {
+	TraceId      string            `json:"trace_id"`
+	SpanId       string            `json:"span_id"`
+	ParentSpanId string            `json:"parent_span_id"`
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	ServiceName  string            `json:"service_name"`
+	Start        string            `json:"start"`
+	Attributes   map[string]string `json:"attributes"`
+	Events       []spanStateEvent  `json:"events,omitempty"`
+}
+
+// spanStateEvent is an event added to a pending span by otel-cli span event.
+type spanStateEvent struct {
+	Name       string            `json:"name"`
+	Time       string            `json:"time"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// newSpanState returns the state to save for a span that was just started.
+func newSpanState(config Config, span *tracepb.Span) spanState {
+	return spanState{
+		TraceId:      hex.EncodeToString(span.TraceId),
+		SpanId:       hex.EncodeToString(span.SpanId),
+		ParentSpanId: hex.EncodeToString(span.ParentSpanId),
+		Name:         span.Name,
+		Kind:         otlpclient.SpanKindIntToString(span.Kind),
+		ServiceName:  config.ServiceName,
+		Start:        time.Unix(0, int64(span.StartTimeUnixNano)).UTC().Format(time.RFC3339Nano),
+		Attributes:   otlpclient.SpanAttributesToStringMap(span),
+	}
+}
+
+// span returns the pending span as a protobuf span, without an end time.
+func (ss spanState) span() (*tracepb.Span, error) {
+	span := otlpclient.NewProtobufSpan()
+	var err error
+	if span.TraceId, err = hex.DecodeString(ss.TraceId); err != nil {
+		return nil, fmt.Errorf("invalid trace id %q: %w", ss.TraceId, err)
+	}
+	if span.SpanId, err = hex.DecodeString(ss.SpanId); err != nil {
+		return nil, fmt.Errorf("invalid span id %q: %w", ss.SpanId, err)
+	}
+	if span.ParentSpanId, err = hex.DecodeString(ss.ParentSpanId); err != nil {
+		return nil, fmt.Errorf("invalid parent span id %q: %w", ss.ParentSpanId, err)
+	}
+	start, err := time.Parse(time.RFC3339Nano, ss.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time: %w", err)
+	}
+
+	span.Name = ss.Name
+	span.Kind = otlpclient.SpanKindStringToInt(ss.Kind)
+	span.StartTimeUnixNano = uint64(start.UnixNano())
+	span.EndTimeUnixNano = 0
+	span.Attributes = otlpclient.StringMapAttrsToProtobuf(ss.Attributes)
+
+	for _, e := range ss.Events {
+		ts, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time for event %q: %w", e.Name, err)
+		}
+		event := otlpclient.NewProtobufSpanEvent()
+		event.Name = e.Name
+		event.TimeUnixNano = uint64(ts.UnixNano())
+		event.Attributes = otlpclient.StringMapAttrsToProtobuf(e.Attributes)
+		span.Events = append(span.Events, event)
+	}
+
+	return span, nil
+}
+
+// errNoSpanState is returned when there's no --state to load or lock.
+var errNoSpanState = errors.New("a state file or directory is required, set --state or OTEL_CLI_SPAN_STATE")
+
+// lockSpanStates takes an exclusive lock on a lock file next to path, so
+// span start, event, and finish running at the same time take turns
+// loading and saving instead of losing each other's changes. Call the
+// returned function to unlock. The lock file is left in place, since
+// removing it would let two commands lock different files.
+func lockSpanStates(path string) (func(), error) {
+	if path == "" {
+		return nil, errNoSpanState
+	}
+
+	f, err := os.OpenFile(filepath.Clean(path)+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to lock span state %q: %w", path, err)
+	}
+	// closing the file releases the lock
+	return func() { f.Close() }, nil
+}
+
+// loadSpanStates returns the pending spans saved at path, outermost first.
+// path is either a file holding all of them as a JSON list, or a directory
+// with a numbered file for each. Nothing at path means there are none.
+// Callers hold lockSpanStates from here until they've saved.
+func loadSpanStates(path string) ([]spanState, error) {
+	if path == "" {
+		return nil, errNoSpanState
+	}
+
+	states := []spanState{}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		} else if err = json.Unmarshal(data, &states); err != nil {
+			return nil, fmt.Errorf("invalid span state file %q: %w", path, err)
+		}
+		return states, nil
+	}
+
+	files, err := spanStateFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var state spanState
+		if err = json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("invalid span state file %q: %w", file, err)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// saveSpanStates writes the pending spans to path the way loadSpanStates
+// reads them. The state file is removed when there are none left, the
+// directory is left in place.
+func saveSpanStates(path string, states []spanState) error {
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if info == nil || !info.IsDir() {
+		if len(states) == 0 {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		}
+		data, err := json.MarshalIndent(states, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, append(data, '\n'))
+	}
+
+	old, err := spanStateFiles(path)
+	if err != nil {
+		return err
+	}
+	written := map[string]bool{}
+	for i, state := range states {
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return err
+		}
+		file := spanStateFile(path, i)
+		if err = writeFileAtomic(file, append(data, '\n')); err != nil {
+			return err
+		}
+		written[file] = true
+	}
+	for _, file := range old {
+		if !written[file] {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// spanStateFile returns the file for the pending span at depth i, named so
+// they sort outermost first.
+func spanStateFile(dir string, i int) string {
+	return filepath.Join(dir, fmt.Sprintf("span-%04d.json", i))
+}
+
+// spanStateFiles returns the pending span files in dir, outermost first.
+func spanStateFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "span-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path then renames
+// it into place, so a killed otel-cli never leaves half a state file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	} else if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,121 @@
+package otelcli
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpanStates(t *testing.T) {
+	outer := spanState{
+		TraceId:    "e39280f2980af3a8600ae98c74f2dabf",
+		SpanId:     "aaaaaaaaaaaaaaaa",
+		Name:       "build",
+		Kind:       "internal",
+		Start:      "2023-11-14T22:13:20Z",
+		Attributes: map[string]string{"step": "1"},
+	}
+	inner := spanState{
+		TraceId:      "e39280f2980af3a8600ae98c74f2dabf",
+		SpanId:       "bbbbbbbbbbbbbbbb",
+		ParentSpanId: "aaaaaaaaaaaaaaaa",
+		Name:         "test",
+		Kind:         "internal",
+		Start:        "2023-11-14T22:13:21.5Z",
+		Attributes:   map[string]string{},
+		Events:       []spanStateEvent{{Name: "compiled", Time: "2023-11-14T22:13:22Z"}},
+	}
+
+	dir := t.TempDir()
+	stackDir := filepath.Join(dir, "stack")
+	if err := os.Mkdir(stackDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "state.json"), stackDir} {
+		states, err := loadSpanStates(path)
+		if err != nil || len(states) != 0 {
+			t.Fatalf("expected no pending spans in %s, got %v, %v", path, states, err)
+		}
+
+		// push, push, pop, pop
+		for _, want := range [][]spanState{{outer}, {outer, inner}, {outer}, {}} {
+			if err := saveSpanStates(path, want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadSpanStates(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("pending spans in %s did not match (-want +got):\n%s", path, diff)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "state.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the state file to be removed when empty, got %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(stackDir, "*")); len(files) != 0 {
+		t.Errorf("expected the stack directory to be empty, got %v", files)
+	}
+
+	span, err := inner.span()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span.Name != "test" || len(span.Events) != 1 || span.EndTimeUnixNano != 0 ||
+		newSpanState(DefaultConfig(), span).ParentSpanId != inner.ParentSpanId {
+		t.Errorf("span from state did not match: %v", span)
+	}
+
+	if _, err := loadSpanStates(""); err == nil {
+		t.Error("expected an error without a state path")
+	}
+}
+
+func TestSpanStatesLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// every push has to see the ones before it or spans go missing
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockSpanStates(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+
+			states, err := loadSpanStates(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			states = append(states, spanState{SpanId: strconv.Itoa(i)})
+			if err := saveSpanStates(path, states); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	states, err := loadSpanStates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 20 {
+		t.Errorf("expected 20 pending spans after concurrent pushes but got %d", len(states))
+	}
+
+	if _, err := lockSpanStates(""); err == nil {
+		t.Error("expected an error locking without a state path")
+	}
+}
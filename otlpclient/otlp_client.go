@@ -13,6 +13,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
@@ -22,6 +24,8 @@ import (
 type OTLPClient interface {
 	Start(context.Context) (context.Context, error)
 	UploadTraces(context.Context, []*tracepb.ResourceSpans) (context.Context, error)
+	UploadLogs(context.Context, []*logspb.ResourceLogs) (context.Context, error)
+	UploadMetrics(context.Context, []*metricspb.ResourceMetrics) (context.Context, error)
 	Stop(context.Context) (context.Context, error)
 }
 
@@ -30,6 +34,8 @@ type OTLPConfig interface {
 	GetTlsConfig() *tls.Config
 	GetIsRecording() bool
 	GetEndpoint() *url.URL
+	GetLogsEndpoint() *url.URL
+	GetMetricsEndpoint() *url.URL
 	GetInsecure() bool
 	GetTimeout() time.Duration
 	GetHeaders() map[string]string
@@ -39,6 +45,11 @@ type OTLPConfig interface {
 
 // SendSpan connects to the OTLP server, sends the span, and disconnects.
 func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *tracepb.Span) (context.Context, error) {
+	return SendSpans(ctx, client, config, []*tracepb.Span{span})
+}
+
+// SendSpans is like SendSpan but sends all of the spans in one request.
+func SendSpans(ctx context.Context, client OTLPClient, config OTLPConfig, spans []*tracepb.Span) (context.Context, error) {
 	if !config.GetIsRecording() {
 		return ctx, nil
 	}
@@ -60,7 +71,7 @@ func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *t
 					Attributes:             []*commonpb.KeyValue{},
 					DroppedAttributesCount: 0,
 				},
-				Spans:     []*tracepb.Span{span},
+				Spans:     spans,
 				SchemaUrl: semconv.SchemaURL,
 			}},
 			SchemaUrl: semconv.SchemaURL,
@@ -75,6 +86,44 @@ func SendSpan(ctx context.Context, client OTLPClient, config OTLPConfig, span *t
 	return ctx, nil
 }
 
+// SendLogs sends the log records in one request, with extra resource
+// attributes added to the usual service ones, e.g. to describe the process
+// that wrote them.
+func SendLogs(ctx context.Context, client OTLPClient, config OTLPConfig, extraResourceAttrs []*commonpb.KeyValue, records []*logspb.LogRecord) (context.Context, error) {
+	if !config.GetIsRecording() || len(records) == 0 {
+		return ctx, nil
+	}
+
+	resourceAttrs, err := resourceAttributes(ctx, config.GetServiceName())
+	if err != nil {
+		return ctx, err
+	}
+
+	rls := []*logspb.ResourceLogs{
+		{
+			Resource: &resourcepb.Resource{
+				Attributes: append(resourceAttrs, extraResourceAttrs...),
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope: &commonpb.InstrumentationScope{
+					Name:    "github.com/tobert/otel-cli",
+					Version: config.GetVersion(),
+				},
+				LogRecords: records,
+				SchemaUrl:  semconv.SchemaURL,
+			}},
+			SchemaUrl: semconv.SchemaURL,
+		},
+	}
+
+	ctx, err = client.UploadLogs(ctx, rls)
+	if err != nil {
+		return SaveError(ctx, time.Now(), err)
+	}
+
+	return ctx, nil
+}
+
 // resourceAttributes calls the OTel SDK to get automatic resource attrs and
 // returns them converted to []*commonpb.KeyValue for use with protobuf.
 func resourceAttributes(ctx context.Context, serviceName string) ([]*commonpb.KeyValue, error) {
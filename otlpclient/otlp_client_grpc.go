@@ -3,9 +3,14 @@ package otlpclient
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"time"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
@@ -18,9 +23,13 @@ import (
 
 // GrpcClient holds the state for gRPC connections.
 type GrpcClient struct {
-	conn   *grpc.ClientConn
-	client coltracepb.TraceServiceClient
-	config OTLPConfig
+	conn          *grpc.ClientConn
+	logsConn      *grpc.ClientConn // same as conn unless the logs endpoint differs
+	metricsConn   *grpc.ClientConn // same as conn unless the metrics endpoint differs
+	client        coltracepb.TraceServiceClient
+	logsClient    collogspb.LogsServiceClient
+	metricsClient colmetricspb.MetricsServiceClient
+	config        OTLPConfig
 }
 
 // NewGrpcClient returns a fresh GrpcClient ready to Start.
@@ -30,13 +39,11 @@ func NewGrpcClient(config OTLPConfig) *GrpcClient {
 }
 
 // Start configures and starts the connection to the gRPC server in the background.
+// Each signal's endpoint gets its own connection, unless it's the same
+// target as the traces endpoint.
 func (gc *GrpcClient) Start(ctx context.Context) (context.Context, error) {
 	var err error
-	endpointURL := gc.config.GetEndpoint()
-	host := endpointURL.Hostname()
-	if endpointURL.Port() != "" {
-		host = host + ":" + endpointURL.Port()
-	}
+	target := grpcTarget(gc.config.GetEndpoint())
 
 	grpcOpts := []grpc.DialOption{}
 
@@ -46,12 +53,29 @@ func (gc *GrpcClient) Start(ctx context.Context) (context.Context, error) {
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(gc.config.GetTlsConfig())))
 	}
 
-	gc.conn, err = grpc.DialContext(ctx, host, grpcOpts...)
+	gc.conn, err = grpc.DialContext(ctx, target, grpcOpts...)
 	if err != nil {
 		return ctx, fmt.Errorf("could not connect to gRPC/OTLP: %w", err)
 	}
 
+	gc.logsConn = gc.conn
+	if logsTarget := grpcTarget(gc.config.GetLogsEndpoint()); logsTarget != target {
+		gc.logsConn, err = grpc.DialContext(ctx, logsTarget, grpcOpts...)
+		if err != nil {
+			return ctx, fmt.Errorf("could not connect to gRPC/OTLP logs endpoint: %w", err)
+		}
+	}
+	gc.metricsConn = gc.conn
+	if metricsTarget := grpcTarget(gc.config.GetMetricsEndpoint()); metricsTarget != target {
+		gc.metricsConn, err = grpc.DialContext(ctx, metricsTarget, grpcOpts...)
+		if err != nil {
+			return ctx, fmt.Errorf("could not connect to gRPC/OTLP metrics endpoint: %w", err)
+		}
+	}
+
 	gc.client = coltracepb.NewTraceServiceClient(gc.conn)
+	gc.logsClient = collogspb.NewLogsServiceClient(gc.logsConn)
+	gc.metricsClient = colmetricspb.NewMetricsServiceClient(gc.metricsConn)
 
 	return ctx, nil
 }
@@ -75,11 +99,62 @@ func (gc *GrpcClient) UploadTraces(ctx context.Context, rsps []*tracepb.Resource
 	})
 }
 
-// Stop closes the connection to the gRPC server.
+// UploadLogs is UploadTraces for log records, sent to the logs endpoint.
+func (gc *GrpcClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	headers := gc.config.GetHeaders()
+	if len(headers) > 0 {
+		md := metadata.New(headers)
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	req := collogspb.ExportLogsServiceRequest{ResourceLogs: rls}
+
+	return retry(ctx, gc.config, func(innerCtx context.Context) (context.Context, bool, time.Duration, error) {
+		_, err := gc.logsClient.Export(innerCtx, &req)
+		return processGrpcStatus(innerCtx, nil, err)
+	})
+}
+
+// UploadMetrics is UploadTraces for metrics, sent to the metrics endpoint.
+func (gc *GrpcClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	headers := gc.config.GetHeaders()
+	if len(headers) > 0 {
+		md := metadata.New(headers)
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	req := colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: rms}
+
+	return retry(ctx, gc.config, func(innerCtx context.Context) (context.Context, bool, time.Duration, error) {
+		_, err := gc.metricsClient.Export(innerCtx, &req)
+		return processGrpcStatus(innerCtx, nil, err)
+	})
+}
+
+// Stop closes the connections to the gRPC server.
 func (gc *GrpcClient) Stop(ctx context.Context) (context.Context, error) {
+	for _, conn := range []*grpc.ClientConn{gc.logsConn, gc.metricsConn} {
+		if conn != gc.conn {
+			conn.Close()
+		}
+	}
 	return ctx, gc.conn.Close()
 }
 
+// grpcTarget returns the gRPC dial target for an endpoint URL, which is
+// host:port, or the unix:///path URL as is since gRPC's default resolver
+// understands those natively.
+func grpcTarget(endpointURL *url.URL) string {
+	if endpointURL.Scheme == "unix" {
+		return "unix://" + endpointURL.Path
+	}
+	host := endpointURL.Hostname()
+	if endpointURL.Port() != "" {
+		host = host + ":" + endpointURL.Port()
+	}
+	return host
+}
+
 func processGrpcStatus(ctx context.Context, _ *coltracepb.ExportTraceServiceResponse, err error) (context.Context, bool, time.Duration, error) {
 	if err == nil {
 		// success!
@@ -2,6 +2,7 @@ package otlpclient
 
 import (
 	"context"
+	"net/url"
 	"testing"
 	"time"
 
@@ -94,3 +95,19 @@ func retryWithInfo(wait int64) error {
 
 	return st.Err()
 }
+
+func TestGrpcTarget(t *testing.T) {
+	for in, want := range map[string]string{
+		"grpc://localhost:4317":         "localhost:4317",
+		"http://127.0.0.1:4318/v1/logs": "127.0.0.1:4318",
+		"unix:///tmp/otlp.sock":         "unix:///tmp/otlp.sock",
+	} {
+		u, err := url.Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := grpcTarget(u); got != want {
+			t.Errorf("grpcTarget(%q) = %q, wanted %q", in, got, want)
+		}
+	}
+}
@@ -11,16 +11,29 @@ import (
 	"net/url"
 	"time"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
+// unixHttpTracesURL and friends are the URLs posted to when sending
+// OTLP/HTTP over a unix socket.
+const (
+	unixHttpTracesURL  = "http://localhost/v1/traces"
+	unixHttpLogsURL    = "http://localhost/v1/logs"
+	unixHttpMetricsURL = "http://localhost/v1/metrics"
+)
+
 // HttpClient holds state information for HTTP/OTLP.
 type HttpClient struct {
-	client *http.Client
-	config OTLPConfig
+	client      *http.Client
+	unixClients map[string]*http.Client // by socket file, for unix:// endpoints
+	config      OTLPConfig
 }
 
 // NewHttpClient returns an initialized HttpClient.
@@ -29,9 +42,29 @@ func NewHttpClient(config OTLPConfig) *HttpClient {
 	return &c
 }
 
-// Start sets up the client configuration.
+// Start sets up the client configuration. Each signal's endpoint can be a
+// unix socket or not independently of the others, so every socket gets its
+// own client and the rest share one.
 // TODO: see if there's a way to background start http2 connections?
 func (hc *HttpClient) Start(ctx context.Context) (context.Context, error) {
+	hc.unixClients = map[string]*http.Client{}
+	for _, endpointURL := range []*url.URL{hc.config.GetEndpoint(), hc.config.GetLogsEndpoint(), hc.config.GetMetricsEndpoint()} {
+		if endpointURL == nil || endpointURL.Scheme != "unix" {
+			continue
+		}
+		// every connection goes to the socket, whatever host is in the request URL
+		sockfile := endpointURL.Path
+		hc.unixClients[sockfile] = &http.Client{
+			Timeout: hc.config.GetTimeout(),
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", sockfile)
+				},
+			},
+		}
+	}
+
 	if hc.config.GetInsecure() {
 		hc.client = &http.Client{Timeout: hc.config.GetTimeout()}
 	} else {
@@ -50,14 +83,36 @@ func (hc *HttpClient) Start(ctx context.Context) (context.Context, error) {
 // UploadTraces sends the protobuf spans up to the HTTP server.
 func (hc *HttpClient) UploadTraces(ctx context.Context, rsps []*tracepb.ResourceSpans) (context.Context, error) {
 	msg := coltracepb.ExportTraceServiceRequest{ResourceSpans: rsps}
-	protoMsg, err := proto.Marshal(&msg)
+	return hc.upload(ctx, &msg, hc.config.GetEndpoint(), unixHttpTracesURL, processHTTPStatus)
+}
+
+// UploadLogs sends the protobuf log records up to the HTTP server.
+func (hc *HttpClient) UploadLogs(ctx context.Context, rls []*logspb.ResourceLogs) (context.Context, error) {
+	msg := collogspb.ExportLogsServiceRequest{ResourceLogs: rls}
+	return hc.upload(ctx, &msg, hc.config.GetLogsEndpoint(), unixHttpLogsURL, processHTTPLogsStatus)
+}
+
+// UploadMetrics sends the protobuf metrics up to the HTTP server.
+func (hc *HttpClient) UploadMetrics(ctx context.Context, rms []*metricspb.ResourceMetrics) (context.Context, error) {
+	msg := colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: rms}
+	return hc.upload(ctx, &msg, hc.config.GetMetricsEndpoint(), unixHttpMetricsURL, processHTTPMetricsStatus)
+}
+
+// upload posts an export request for any signal to the endpoint, or to
+// unixURL over a unix socket, and checks the response with process.
+func (hc *HttpClient) upload(ctx context.Context, msg proto.Message, endpointURL *url.URL, unixURL string, process httpStatusFun) (context.Context, error) {
+	protoMsg, err := proto.Marshal(msg)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to marshal trace service request: %w", err)
+		return ctx, fmt.Errorf("failed to marshal export service request: %w", err)
 	}
 	body := bytes.NewBuffer(protoMsg)
 
-	endpointURL := hc.config.GetEndpoint()
-	req, err := http.NewRequest("POST", endpointURL.String(), body)
+	client, postURL := hc.client, endpointURL.String()
+	if endpointURL.Scheme == "unix" {
+		// the path is the socket file, so the request gets the default signal path
+		client, postURL = hc.unixClients[endpointURL.Path], unixURL
+	}
+	req, err := http.NewRequest("POST", postURL, body)
 	if err != nil {
 		return ctx, fmt.Errorf("failed to create HTTP POST request: %w", err)
 	}
@@ -69,7 +124,7 @@ func (hc *HttpClient) UploadTraces(ctx context.Context, rsps []*tracepb.Resource
 
 	return retry(ctx, hc.config, func(context.Context) (context.Context, bool, time.Duration, error) {
 		var body []byte
-		resp, err := hc.client.Do(req)
+		resp, err := client.Do(req)
 		if uerr, ok := err.(*url.Error); ok {
 			// e.g. http on https, un-retriable error, quit now
 			return ctx, false, 0, uerr
@@ -80,14 +135,46 @@ func (hc *HttpClient) UploadTraces(ctx context.Context, rsps []*tracepb.Resource
 			}
 			resp.Body.Close()
 
-			return processHTTPStatus(ctx, resp, body)
+			return process(ctx, resp, body)
 		}
 	})
 }
 
+// httpStatusFun is the signature of processHTTPStatus and friends.
+type httpStatusFun func(context.Context, *http.Response, []byte) (context.Context, bool, time.Duration, error)
+
 // processHTTPStatus takes the http.Response and body, returning the same bool, error
 // as retryFunc. Mostly it's broken out so it can be unit tested.
 func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte) (context.Context, bool, time.Duration, error) {
+	return processHTTPResponse(ctx, resp, body, "spans", func(body []byte) (int64, error) {
+		etsr := coltracepb.ExportTraceServiceResponse{}
+		err := proto.Unmarshal(body, &etsr)
+		return etsr.GetPartialSuccess().GetRejectedSpans(), err
+	})
+}
+
+// processHTTPLogsStatus is processHTTPStatus for the logs signal.
+func processHTTPLogsStatus(ctx context.Context, resp *http.Response, body []byte) (context.Context, bool, time.Duration, error) {
+	return processHTTPResponse(ctx, resp, body, "log records", func(body []byte) (int64, error) {
+		elsr := collogspb.ExportLogsServiceResponse{}
+		err := proto.Unmarshal(body, &elsr)
+		return elsr.GetPartialSuccess().GetRejectedLogRecords(), err
+	})
+}
+
+// processHTTPMetricsStatus is processHTTPStatus for the metrics signal.
+func processHTTPMetricsStatus(ctx context.Context, resp *http.Response, body []byte) (context.Context, bool, time.Duration, error) {
+	return processHTTPResponse(ctx, resp, body, "data points", func(body []byte) (int64, error) {
+		emsr := colmetricspb.ExportMetricsServiceResponse{}
+		err := proto.Unmarshal(body, &emsr)
+		return emsr.GetPartialSuccess().GetRejectedDataPoints(), err
+	})
+}
+
+// processHTTPResponse does the work for processHTTPStatus and friends, with
+// rejected unmarshaling a successful response and returning how many of what
+// was sent (e.g. spans) the server rejected.
+func processHTTPResponse(ctx context.Context, resp *http.Response, body []byte, what string, rejected func([]byte) (int64, error)) (context.Context, bool, time.Duration, error) {
 	// #262 a vendor OTLP server is out of spec and returns JSON instead of protobuf
 	ctype := resp.Header.Get("Content-Type")
 	if ctype == "" {
@@ -99,16 +186,15 @@ func processHTTPStatus(ctx context.Context, resp *http.Response, body []byte) (c
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		// success & partial success
 		// spec says server MUST send 200 OK, we'll be generous and accept any 200
-		etsr := coltracepb.ExportTraceServiceResponse{}
-		err := proto.Unmarshal(body, &etsr)
+		count, err := rejected(body)
 		if err != nil {
 			// if the server's sending garbage, no point in retrying
 			return ctx, false, 0, fmt.Errorf("unmarshal of server response failed: %w", err)
 		}
 
-		if partial := etsr.GetPartialSuccess(); partial != nil && partial.RejectedSpans > 0 {
+		if count > 0 {
 			// spec says to stop retrying and drop rejected spans
-			return ctx, false, 0, fmt.Errorf("partial success. %d spans were rejected", partial.GetRejectedSpans())
+			return ctx, false, 0, fmt.Errorf("partial success. %d %s were rejected", count, what)
 
 		} else {
 			// full success!
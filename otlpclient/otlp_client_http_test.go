@@ -2,9 +2,16 @@ package otlpclient
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
@@ -176,3 +183,90 @@ func errorBody(c int32, message string) []byte {
 	b, _ := proto.Marshal(&st)
 	return b
 }
+
+// testHttpConfig is just enough OTLPConfig for an HttpClient.
+type testHttpConfig struct {
+	traces, logs, metrics *url.URL
+}
+
+func (c testHttpConfig) GetTlsConfig() *tls.Config     { return &tls.Config{} }
+func (c testHttpConfig) GetIsRecording() bool          { return true }
+func (c testHttpConfig) GetEndpoint() *url.URL         { return c.traces }
+func (c testHttpConfig) GetLogsEndpoint() *url.URL     { return c.logs }
+func (c testHttpConfig) GetMetricsEndpoint() *url.URL  { return c.metrics }
+func (c testHttpConfig) GetInsecure() bool             { return true }
+func (c testHttpConfig) GetTimeout() time.Duration     { return time.Second }
+func (c testHttpConfig) GetHeaders() map[string]string { return map[string]string{} }
+func (c testHttpConfig) GetVersion() string            { return "test" }
+func (c testHttpConfig) GetServiceName() string        { return "test" }
+
+func TestHttpClientMixedEndpoints(t *testing.T) {
+	// each server records the paths posted to it
+	newServer := func(unixSock string) (*httptest.Server, *[]string) {
+		paths := []string{}
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			paths = append(paths, req.URL.Path)
+			rw.Header().Set("Content-Type", "application/x-protobuf")
+		}))
+		if unixSock != "" {
+			listener, err := net.Listen("unix", unixSock)
+			if err != nil {
+				t.Fatalf("failed to listen on %q: %s", unixSock, err)
+			}
+			srv.Listener.Close()
+			srv.Listener = listener
+		}
+		srv.Start()
+		t.Cleanup(srv.Close)
+		return srv, &paths
+	}
+
+	sockfile := filepath.Join(t.TempDir(), "otlp.sock")
+	_, unixPaths := newServer(sockfile)
+	tcpSrv, tcpPaths := newServer("")
+	unixURL := &url.URL{Scheme: "unix", Path: sockfile}
+	tcpURL := func(path string) *url.URL {
+		u, _ := url.Parse(tcpSrv.URL + path)
+		return u
+	}
+
+	for _, tc := range []struct {
+		config            testHttpConfig
+		wantUnix, wantTcp []string
+	}{
+		{
+			config:   testHttpConfig{traces: unixURL, logs: tcpURL("/v1/logs"), metrics: tcpURL("/v1/metrics")},
+			wantUnix: []string{"/v1/traces"},
+			wantTcp:  []string{"/v1/logs", "/v1/metrics"},
+		},
+		{
+			config:   testHttpConfig{traces: tcpURL("/v1/traces"), logs: unixURL, metrics: tcpURL("/v1/metrics")},
+			wantUnix: []string{"/v1/logs"},
+			wantTcp:  []string{"/v1/traces", "/v1/metrics"},
+		},
+	} {
+		*unixPaths, *tcpPaths = []string{}, []string{}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		hc := NewHttpClient(tc.config)
+		ctx, _ = hc.Start(ctx)
+		for name, upload := range map[string]func() (context.Context, error){
+			"traces":  func() (context.Context, error) { return hc.UploadTraces(ctx, nil) },
+			"logs":    func() (context.Context, error) { return hc.UploadLogs(ctx, nil) },
+			"metrics": func() (context.Context, error) { return hc.UploadMetrics(ctx, nil) },
+		} {
+			if _, err := upload(); err != nil {
+				t.Errorf("uploading %s failed: %s", name, err)
+			}
+		}
+		cancel()
+
+		sort.Strings(tc.wantTcp)
+		sort.Strings(*tcpPaths)
+		if diff := cmp.Diff(tc.wantUnix, *unixPaths); diff != "" {
+			t.Errorf("unix socket got the wrong requests (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(tc.wantTcp, *tcpPaths); diff != "" {
+			t.Errorf("tcp endpoint got the wrong requests (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -218,6 +218,8 @@ func AnyValueToString(v *commonpb.AnyValue) string {
 		return strconv.FormatInt(v.GetIntValue(), 10)
 	} else if _, ok := v.Value.(*commonpb.AnyValue_DoubleValue); ok {
 		return strconv.FormatFloat(v.GetDoubleValue(), byte('f'), -1, 64)
+	} else if _, ok := v.Value.(*commonpb.AnyValue_BoolValue); ok {
+		return strconv.FormatBool(v.GetBoolValue())
 	} else if _, ok := v.Value.(*commonpb.AnyValue_ArrayValue); ok {
 		values := v.GetArrayValue().GetValues()
 		strValues := make([]string, len(values))
@@ -249,3 +249,21 @@ func TestCliAttrsToOtel(t *testing.T) {
 		}
 	}
 }
+
+func TestAnyValueToString(t *testing.T) {
+	for _, tc := range []struct {
+		in     map[string]string
+		expect string
+	}{
+		{map[string]string{"k": "a string"}, "a string"},
+		{map[string]string{"k": "42"}, "42"},
+		{map[string]string{"k": "4.2"}, "4.2"},
+		{map[string]string{"k": "true"}, "true"},
+		{map[string]string{"k": "False"}, "false"},
+	} {
+		got := AnyValueToString(StringMapAttrsToProtobuf(tc.in)[0].Value)
+		if got != tc.expect {
+			t.Errorf("expected %q but got %q", tc.expect, got)
+		}
+	}
+}
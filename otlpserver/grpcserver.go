@@ -7,21 +7,36 @@ import (
 	"log"
 	"net"
 	"sync"
+	"time"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// grpcRetryDelay is the RetryInfo sent with RESOURCE_EXHAUSTED when the
+// pipeline is full, which tells OTLP clients the error is retryable.
+const grpcRetryDelay = 100 * time.Millisecond
+
 // GrpcServer is a gRPC/OTLP server handle.
 type GrpcServer struct {
-	server   *grpc.Server
-	callback Callback
-	stoponce sync.Once
-	stopper  chan struct{}
-	stopdone chan struct{}
-	doneonce sync.Once
+	server          *grpc.Server
+	callback        Callback
+	logsCallback    LogsCallback
+	metricsCallback MetricsCallback
+	requestCallback RequestCallback
+	pipeline        *Pipeline
+	stoponce        sync.Once
+	stopper         chan struct{}
+	stopdone        chan struct{}
+	doneonce        sync.Once
 	coltracepb.UnimplementedTraceServiceServer
 }
 
@@ -32,11 +47,15 @@ func NewGrpcServer(cb Callback, stop Stopper, opts ...grpc.ServerOption) *GrpcSe
 	s := GrpcServer{
 		server:   grpc.NewServer(opts...),
 		callback: cb,
+		pipeline: NewPipeline(DefaultPipelineDepth),
 		stopper:  make(chan struct{}),
 		stopdone: make(chan struct{}, 1),
 	}
 
 	coltracepb.RegisterTraceServiceServer(s.server, &s)
+	// logs and metrics each have an Export method so they need their own types
+	collogspb.RegisterLogsServiceServer(s.server, &grpcLogsService{gs: &s})
+	colmetricspb.RegisterMetricsServiceServer(s.server, &grpcMetricsService{gs: &s})
 
 	// single place to stop the server, used by timeout and max-spans
 	go func() {
@@ -51,15 +70,19 @@ func NewGrpcServer(cb Callback, stop Stopper, opts ...grpc.ServerOption) *GrpcSe
 // ServeGRPC takes a listener and starts the GRPC server on that listener.
 // Blocks until Stop() is called.
 func (gs *GrpcServer) Serve(listener net.Listener) error {
+	served := make(chan struct{})
+	go stopWhenDone(gs, gs.pipeline, served)
+
 	err := gs.server.Serve(listener)
+	close(served)
 	gs.stopdone <- struct{}{}
 	return err
 }
 
-// ListenAndServeGRPC starts a TCP listener then starts the GRPC server using
+// ListenAndServeGRPC starts a TCP or unix socket listener then starts the GRPC server using
 // ServeGRPC for you.
 func (gs *GrpcServer) ListenAndServe(otlpEndpoint string) {
-	listener, err := net.Listen("tcp", otlpEndpoint)
+	listener, err := listen(otlpEndpoint)
 	if err != nil {
 		log.Fatalf("failed to listen on OTLP endpoint %q: %s", otlpEndpoint, err)
 	}
@@ -76,18 +99,116 @@ func (gs *GrpcServer) Stop() {
 	})
 }
 
-// StopWait stops the server and waits for it to affirm shutdown.
+// StopWait stops the server and waits for it to affirm shutdown, then
+// waits for the callbacks to finish with everything already received.
 func (gs *GrpcServer) StopWait() {
 	gs.Stop()
 	gs.doneonce.Do(func() {
 		<-gs.stopdone
 	})
+	// GracefulStop waited on in-flight requests so nothing else can be queued
+	gs.pipeline.Close()
+	gs.pipeline.Wait()
+}
+
+// SetLogsCallback sets the function called for each log record received.
+func (gs *GrpcServer) SetLogsCallback(cb LogsCallback) {
+	gs.logsCallback = cb
+}
+
+// SetMetricsCallback sets the function called for each metric received.
+func (gs *GrpcServer) SetMetricsCallback(cb MetricsCallback) {
+	gs.metricsCallback = cb
+}
+
+// SetRequestCallback sets the function called once for each export request.
+func (gs *GrpcServer) SetRequestCallback(cb RequestCallback) {
+	gs.requestCallback = cb
+}
+
+// SetPipeline replaces the server's pipeline, e.g. to share one with another
+// server. Must be called before Serve.
+func (gs *GrpcServer) SetPipeline(p *Pipeline) {
+	gs.pipeline.Close()
+	gs.pipeline = p
+}
+
+// enqueue queues the callback work for a request and translates pipeline
+// errors to gRPC status errors.
+func (gs *GrpcServer) enqueue(item func() bool) error {
+	switch err := gs.pipeline.Enqueue(item); err {
+	case nil:
+		return nil
+	case ErrPipelineFull:
+		st := status.New(codes.ResourceExhausted, err.Error())
+		if detailed, derr := st.WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(grpcRetryDelay),
+		}); derr == nil {
+			st = detailed
+		}
+		return st.Err()
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
 }
 
 // Export implements the gRPC server interface for exporting messages.
 func (gs *GrpcServer) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
-	// OTLP/gRPC headers are passed in metadata, copy them to serverMeta
-	// for now. This isn't ideal but gets them exposed to the test suite.
+	ctx, headers := context.WithoutCancel(ctx), grpcHeaders(ctx)
+	err := gs.enqueue(func() bool {
+		meta := map[string]string{"proto": "grpc"}
+		return doRequestCallback(ctx, gs.requestCallback, req, headers, meta) ||
+			doCallback(ctx, gs.callback, req, headers, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// grpcLogsService implements the OTLP logs gRPC service for a GrpcServer.
+type grpcLogsService struct {
+	gs *GrpcServer
+	collogspb.UnimplementedLogsServiceServer
+}
+
+// Export implements the gRPC logs service interface for exporting messages.
+func (ls *grpcLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	ctx, headers := context.WithoutCancel(ctx), grpcHeaders(ctx)
+	err := ls.gs.enqueue(func() bool {
+		meta := map[string]string{"proto": "grpc"}
+		return doRequestCallback(ctx, ls.gs.requestCallback, req, headers, meta) ||
+			doLogsCallback(ctx, ls.gs.logsCallback, req, headers, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// grpcMetricsService implements the OTLP metrics gRPC service for a GrpcServer.
+type grpcMetricsService struct {
+	gs *GrpcServer
+	colmetricspb.UnimplementedMetricsServiceServer
+}
+
+// Export implements the gRPC metrics service interface for exporting messages.
+func (ms *grpcMetricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	ctx, headers := context.WithoutCancel(ctx), grpcHeaders(ctx)
+	err := ms.gs.enqueue(func() bool {
+		meta := map[string]string{"proto": "grpc"}
+		return doRequestCallback(ctx, ms.gs.requestCallback, req, headers, meta) ||
+			doMetricsCallback(ctx, ms.gs.metricsCallback, req, headers, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// grpcHeaders copies OTLP/gRPC headers out of the incoming metadata. This
+// isn't ideal but gets them exposed to the test suite.
+func grpcHeaders(ctx context.Context) map[string]string {
 	headers := make(map[string]string)
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		for mdk := range md {
@@ -97,10 +218,5 @@ func (gs *GrpcServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 			headers[mdk] = buf.String()
 		}
 	}
-
-	done := doCallback(ctx, gs.callback, req, headers, map[string]string{"proto": "grpc"})
-	if done {
-		go gs.StopWait()
-	}
-	return &coltracepb.ExportTraceServiceResponse{}, nil
+	return headers
 }
@@ -7,15 +7,27 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/proto"
 )
 
 // HttpServer is a handle for otlp over http/protobuf.
 type HttpServer struct {
-	server   *http.Server
-	callback Callback
+	server          *http.Server
+	callback        Callback
+	logsCallback    LogsCallback
+	metricsCallback MetricsCallback
+	requestCallback RequestCallback
+	pipeline        *Pipeline
+	served          chan struct{}
+	servedonce      sync.Once
 }
 
 // NewServer takes a callback and stop function and returns a Server ready
@@ -24,6 +36,8 @@ func NewHttpServer(cb Callback, stop Stopper) *HttpServer {
 	s := HttpServer{
 		server:   &http.Server{},
 		callback: cb,
+		pipeline: NewPipeline(DefaultPipelineDepth),
+		served:   make(chan struct{}),
 	}
 
 	s.server.Handler = &s
@@ -31,24 +45,37 @@ func NewHttpServer(cb Callback, stop Stopper) *HttpServer {
 	return &s
 }
 
-// ServeHTTP processes every request as if it is a trace regardless of
-// method and path or anything else.
+// SetLogsCallback sets the function called for each log record received.
+func (hs *HttpServer) SetLogsCallback(cb LogsCallback) {
+	hs.logsCallback = cb
+}
+
+// SetMetricsCallback sets the function called for each metric received.
+func (hs *HttpServer) SetMetricsCallback(cb MetricsCallback) {
+	hs.metricsCallback = cb
+}
+
+// SetRequestCallback sets the function called once for each export request.
+func (hs *HttpServer) SetRequestCallback(cb RequestCallback) {
+	hs.requestCallback = cb
+}
+
+// SetPipeline replaces the server's pipeline, e.g. to share one with another
+// server. Must be called before Serve.
+func (hs *HttpServer) SetPipeline(p *Pipeline) {
+	hs.pipeline.Close()
+	hs.pipeline = p
+}
+
+// ServeHTTP routes /v1/logs and /v1/metrics to their signals, Zipkin's span
+// path to the Zipkin translator, and processes every other request as if it
+// is a trace regardless of method and path or anything else.
 func (hs *HttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	data, err := io.ReadAll(req.Body)
 	if err != nil {
 		log.Fatalf("Error while reading request body: %s", err)
 	}
 
-	msg := coltracepb.ExportTraceServiceRequest{}
-	switch req.Header.Get("Content-Type") {
-	case "application/x-protobuf":
-		proto.Unmarshal(data, &msg)
-	case "application/json":
-		json.Unmarshal(data, &msg)
-	default:
-		rw.WriteHeader(http.StatusNotAcceptable)
-	}
-
 	meta := map[string]string{
 		"method":       req.Method,
 		"proto":        req.Proto,
@@ -62,37 +89,167 @@ func (hs *HttpServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		headers[k] = req.Header.Get(k)
 	}
 
-	done := doCallback(req.Context(), hs.callback, &msg, headers, meta)
-	if done {
-		go hs.StopWait()
+	// callbacks run later on the pipeline, after the request is finished
+	ctx := context.WithoutCancel(req.Context())
+
+	switch {
+	case req.URL.Path == ZipkinSpansPath:
+		zspans := []ZipkinSpan{}
+		if err := json.Unmarshal(data, &zspans); err != nil {
+			http.Error(rw, "failed to parse zipkin v2 json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg := coltracepb.ExportTraceServiceRequest{}
+		msg.ResourceSpans, err = ZipkinToResourceSpans(zspans)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		meta["format"] = "zipkin"
+		err = hs.pipeline.Enqueue(func() bool {
+			return doRequestCallback(ctx, hs.requestCallback, &msg, headers, meta) ||
+				doCallback(ctx, hs.callback, &msg, headers, meta)
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), httpPipelineStatus(err))
+			return
+		}
+		// zipkin reporters expect 202 Accepted
+		rw.WriteHeader(http.StatusAccepted)
+	case strings.HasSuffix(req.URL.Path, "/v1/logs"):
+		msg := collogspb.ExportLogsServiceRequest{}
+		if unmarshalHttpBody(rw, req, data, &msg) {
+			err := hs.pipeline.Enqueue(func() bool {
+				return doRequestCallback(ctx, hs.requestCallback, &msg, headers, meta) ||
+					doLogsCallback(ctx, hs.logsCallback, &msg, headers, meta)
+			})
+			if err != nil {
+				writeHttpError(rw, req, err)
+				return
+			}
+			writeHttpResponse(rw, req, &collogspb.ExportLogsServiceResponse{})
+		}
+	case strings.HasSuffix(req.URL.Path, "/v1/metrics"):
+		msg := colmetricspb.ExportMetricsServiceRequest{}
+		if unmarshalHttpBody(rw, req, data, &msg) {
+			err := hs.pipeline.Enqueue(func() bool {
+				return doRequestCallback(ctx, hs.requestCallback, &msg, headers, meta) ||
+					doMetricsCallback(ctx, hs.metricsCallback, &msg, headers, meta)
+			})
+			if err != nil {
+				writeHttpError(rw, req, err)
+				return
+			}
+			writeHttpResponse(rw, req, &colmetricspb.ExportMetricsServiceResponse{})
+		}
+	default:
+		msg := coltracepb.ExportTraceServiceRequest{}
+		if unmarshalHttpBody(rw, req, data, &msg) {
+			err := hs.pipeline.Enqueue(func() bool {
+				return doRequestCallback(ctx, hs.requestCallback, &msg, headers, meta) ||
+					doCallback(ctx, hs.callback, &msg, headers, meta)
+			})
+			if err != nil {
+				writeHttpError(rw, req, err)
+				return
+			}
+			writeHttpResponse(rw, req, &coltracepb.ExportTraceServiceResponse{})
+		}
+	}
+}
+
+// httpPipelineStatus maps pipeline errors to HTTP status codes: 429 when the
+// queue is full so clients back off and retry, 503 when shutting down.
+func httpPipelineStatus(err error) int {
+	if err == ErrPipelineFull {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusServiceUnavailable
+}
+
+// writeHttpError writes a pipeline error as an OTLP error response, which is
+// a google.rpc.Status in the request's content type.
+func writeHttpError(rw http.ResponseWriter, req *http.Request, err error) {
+	code := codes.Unavailable
+	if err == ErrPipelineFull {
+		code = codes.ResourceExhausted
+		rw.Header().Set("Retry-After", "1")
+	}
+	st := status.Status{Code: int32(code), Message: err.Error()}
+
+	var body []byte
+	ctype := req.Header.Get("Content-Type")
+	if ctype == "application/json" {
+		body, _ = json.Marshal(&st)
+	} else {
+		body, _ = proto.Marshal(&st)
+	}
+	rw.Header().Set("Content-Type", ctype)
+	rw.WriteHeader(httpPipelineStatus(err))
+	rw.Write(body)
+}
+
+// unmarshalHttpBody decodes the request body according to its content type.
+// Returns false after writing an error status when the type isn't supported.
+func unmarshalHttpBody(rw http.ResponseWriter, req *http.Request, data []byte, msg proto.Message) bool {
+	switch req.Header.Get("Content-Type") {
+	case "application/x-protobuf":
+		proto.Unmarshal(data, msg)
+	case "application/json":
+		json.Unmarshal(data, msg)
+	default:
+		rw.WriteHeader(http.StatusNotAcceptable)
+		return false
+	}
+	return true
+}
+
+// writeHttpResponse writes the (empty) OTLP export response in the same
+// content type as the request, as the spec requires.
+func writeHttpResponse(rw http.ResponseWriter, req *http.Request, msg proto.Message) {
+	var body []byte
+	ctype := req.Header.Get("Content-Type")
+	if ctype == "application/json" {
+		body, _ = json.Marshal(msg)
+	} else {
+		body, _ = proto.Marshal(msg)
 	}
+	rw.Header().Set("Content-Type", ctype)
+	rw.Write(body)
 }
 
 // ServeHttp takes a listener and starts the HTTP server on that listener.
 // Blocks until Stop() is called.
 func (hs *HttpServer) Serve(listener net.Listener) error {
+	go stopWhenDone(hs, hs.pipeline, hs.served)
 	err := hs.server.Serve(listener)
+	hs.servedonce.Do(func() { close(hs.served) })
 	return err
 }
 
-// ListenAndServeHttp starts a TCP listener then starts the HTTP server using
+// ListenAndServeHttp starts a TCP or unix socket listener then starts the HTTP server using
 // ServeHttp for you.
 func (hs *HttpServer) ListenAndServe(otlpEndpoint string) {
-	listener, err := net.Listen("tcp", otlpEndpoint)
+	listener, err := listen(otlpEndpoint)
 	if err != nil {
 		log.Fatalf("failed to listen on OTLP endpoint %q: %s", otlpEndpoint, err)
 	}
-	if err := hs.Serve(listener); err != nil {
+	if err := hs.Serve(listener); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("failed to serve: %s", err)
 	}
 }
 
 // Stop closes the http server and all active connections immediately.
+// Anything already queued is processed in the background.
 func (hs *HttpServer) Stop() {
 	hs.server.Close()
+	hs.pipeline.Close()
 }
 
-// StopWait stops the http server gracefully.
+// StopWait stops the http server gracefully, then waits for the callbacks
+// to finish with everything already received.
 func (hs *HttpServer) StopWait() {
 	hs.server.Shutdown(context.Background())
+	hs.pipeline.Close()
+	hs.pipeline.Wait()
 }
@@ -0,0 +1,108 @@
+package otlpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHttpServerSignalRouting(t *testing.T) {
+	var spans, logs, metrics int
+	requests := map[string]int{}
+	hs := NewHttpServer(func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool {
+		spans++
+		return false
+	}, func(OtlpServer) {})
+	hs.SetLogsCallback(func(context.Context, *logspb.LogRecord, *logspb.ResourceLogs, map[string]string, map[string]string) bool {
+		logs++
+		return false
+	})
+	hs.SetMetricsCallback(func(context.Context, *metricspb.Metric, *metricspb.ResourceMetrics, map[string]string, map[string]string) bool {
+		metrics++
+		return false
+	})
+	hs.SetRequestCallback(func(_ context.Context, req proto.Message, _ map[string]string, _ map[string]string) bool {
+		requests[string(req.ProtoReflect().Descriptor().Name())]++
+		return false
+	})
+
+	post := func(path string, msg proto.Message) *httptest.ResponseRecorder {
+		body, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatalf("failed to marshal test message: %s", err)
+		}
+		req := httptest.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		rw := httptest.NewRecorder()
+		hs.ServeHTTP(rw, req)
+		return rw
+	}
+
+	logsReq := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			ScopeLogs: []*logspb.ScopeLogs{{
+				LogRecords: []*logspb.LogRecord{{
+					Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "hello"}},
+				}},
+			}},
+		}},
+	}
+	rw := post("/v1/logs", logsReq)
+	if rw.Code != http.StatusOK || rw.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("logs export got status %d with content type %q", rw.Code, rw.Header().Get("Content-Type"))
+	}
+
+	metricsReq := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{{Name: "m1"}, {Name: "m2"}},
+			}},
+		}},
+	}
+	post("/v1/metrics", metricsReq)
+
+	// callbacks run on the pipeline, let it drain before checking
+	hs.StopWait()
+
+	if spans != 0 || logs != 1 || metrics != 2 {
+		t.Errorf("signals routed wrong, got %d spans, %d logs, %d metrics", spans, logs, metrics)
+	}
+	// the request callback sees each request once, however many items are in it
+	if requests["ExportLogsServiceRequest"] != 1 || requests["ExportMetricsServiceRequest"] != 1 || len(requests) != 2 {
+		t.Errorf("expected one logs and one metrics request, got %v", requests)
+	}
+}
+
+func TestHttpServerBackpressure(t *testing.T) {
+	hs := NewHttpServer(nil, func(OtlpServer) {})
+	// block the consumer on the first item, then fill the one queue slot
+	started, block := make(chan struct{}), make(chan struct{})
+	p := NewPipeline(1)
+	p.Enqueue(func() bool { close(started); <-block; return false })
+	<-started
+	p.Enqueue(func() bool { return false })
+	hs.SetPipeline(p)
+	defer close(block)
+
+	req := httptest.NewRequest("POST", "/v1/traces", bytes.NewBuffer([]byte{}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rw := httptest.NewRecorder()
+	hs.ServeHTTP(rw, req)
+
+	if rw.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429 from a full pipeline but got %d", rw.Code)
+	}
+	if rw.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("expected protobuf content type on 429 but got %q", rw.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,103 @@
+package otlpserver
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultPipelineDepth is how many export requests can be waiting for the
+// callbacks before the servers start pushing back on clients.
+const DefaultPipelineDepth = 1000
+
+// ErrPipelineFull is returned by Enqueue when the queue is at capacity. The
+// servers turn it into RESOURCE_EXHAUSTED (gRPC) or 429 (HTTP) so clients
+// back off and retry.
+var ErrPipelineFull = errors.New("otlpserver pipeline is full, try again later")
+
+// ErrPipelineClosed is returned by Enqueue after the pipeline is closed.
+var ErrPipelineClosed = errors.New("otlpserver pipeline is closed")
+
+// Pipeline delivers export requests to the callbacks from a single goroutine,
+// in the order they were received, so callbacks never run concurrently and
+// don't need to do any locking of their own. The queue is bounded and does
+// not block; when it's full Enqueue returns ErrPipelineFull right away.
+//
+// Servers sharing a Pipeline (e.g. OTLP and Zipkin on different ports) share
+// the ordering guarantee, which is the point of being able to share it.
+type Pipeline struct {
+	queue     chan func() bool
+	mu        sync.RWMutex // guards closed so Enqueue never sends on a closed chan
+	closed    bool
+	drained   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPipeline returns a Pipeline with a queue of the given depth and starts
+// its consumer goroutine.
+func NewPipeline(depth int) *Pipeline {
+	p := Pipeline{
+		queue:   make(chan func() bool, depth),
+		drained: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go p.consume()
+
+	return &p
+}
+
+// consume runs each queued item in order. Once an item reports it's done
+// (e.g. --max-spans was reached) the rest of the queue is discarded.
+func (p *Pipeline) consume() {
+	var finished bool
+	for item := range p.queue {
+		if finished {
+			continue
+		}
+		if item() {
+			finished = true
+			close(p.done)
+		}
+	}
+	close(p.drained)
+}
+
+// Enqueue adds an item to the queue without blocking. The item returns true
+// when the server should shut down, same as the callbacks.
+func (p *Pipeline) Enqueue(item func() bool) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPipelineClosed
+	}
+
+	select {
+	case p.queue <- item:
+		return nil
+	default:
+		return ErrPipelineFull
+	}
+}
+
+// Done returns a channel that is closed when a callback reports it's done.
+func (p *Pipeline) Done() <-chan struct{} {
+	return p.done
+}
+
+// Close stops accepting new items. Items already queued are still processed.
+// Safe to call multiple times.
+func (p *Pipeline) Close() {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+	})
+}
+
+// Wait blocks until the queue is closed and every queued item is processed.
+func (p *Pipeline) Wait() {
+	<-p.drained
+}
@@ -0,0 +1,55 @@
+package otlpserver
+
+import (
+	"testing"
+)
+
+func TestPipelineOrderAndDrain(t *testing.T) {
+	p := NewPipeline(100)
+	got := []int{}
+	for i := 0; i < 100; i++ {
+		i := i
+		if err := p.Enqueue(func() bool { got = append(got, i); return false }); err != nil {
+			t.Fatalf("enqueue %d failed: %s", i, err)
+		}
+	}
+	p.Close()
+	p.Wait()
+
+	if len(got) != 100 {
+		t.Fatalf("expected 100 items to be processed after drain but got %d", len(got))
+	}
+	for i, v := range got {
+		if i != v {
+			t.Fatalf("items processed out of order, item %d was %d", i, v)
+		}
+	}
+
+	if err := p.Enqueue(func() bool { return false }); err != ErrPipelineClosed {
+		t.Errorf("expected ErrPipelineClosed after Close but got %v", err)
+	}
+}
+
+func TestPipelineFullAndDone(t *testing.T) {
+	started, block := make(chan struct{}), make(chan struct{})
+	p := NewPipeline(1)
+	p.Enqueue(func() bool { close(started); <-block; return true })
+	<-started
+
+	var ranAfterDone bool
+	if err := p.Enqueue(func() bool { ranAfterDone = true; return false }); err != nil {
+		t.Fatalf("expected the queue to have room for one item but got %s", err)
+	}
+	if err := p.Enqueue(func() bool { return false }); err != ErrPipelineFull {
+		t.Errorf("expected ErrPipelineFull but got %v", err)
+	}
+
+	close(block)
+	<-p.Done()
+	p.Close()
+	p.Wait()
+
+	if ranAfterDone {
+		t.Error("items queued after a callback reported done should be dropped")
+	}
+}
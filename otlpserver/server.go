@@ -8,17 +8,38 @@ import (
 	"context"
 	"crypto/tls"
 	"net"
+	"os"
+	"strings"
 
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	colv1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
 )
 
 // Callback is a type for the function passed to newServer that is
 // called for each incoming span.
 type Callback func(context.Context, *tracepb.Span, []*tracepb.Span_Event, *tracepb.ResourceSpans, map[string]string, map[string]string) bool
 
+// LogsCallback is a type for the function set with SetLogsCallback that is
+// called for each incoming log record.
+type LogsCallback func(context.Context, *logspb.LogRecord, *logspb.ResourceLogs, map[string]string, map[string]string) bool
+
+// MetricsCallback is a type for the function set with SetMetricsCallback that
+// is called for each incoming metric.
+type MetricsCallback func(context.Context, *metricspb.Metric, *metricspb.ResourceMetrics, map[string]string, map[string]string) bool
+
+// RequestCallback is a type for the function set with SetRequestCallback that
+// is called once for each export request with the whole request, one of
+// *ExportTraceServiceRequest, *ExportLogsServiceRequest, or
+// *ExportMetricsServiceRequest. It runs ahead of the per-item callbacks.
+type RequestCallback func(context.Context, proto.Message, map[string]string, map[string]string) bool
+
 // Stopper is the function passed to newServer to be called when the
 // server is shut down.
 type Stopper func(OtlpServer)
@@ -28,6 +49,10 @@ type Stopper func(OtlpServer)
 type OtlpServer interface {
 	ListenAndServe(otlpEndpoint string)
 	Serve(listener net.Listener) error
+	SetLogsCallback(LogsCallback)
+	SetMetricsCallback(MetricsCallback)
+	SetRequestCallback(RequestCallback)
+	SetPipeline(*Pipeline)
 	Stop()
 	StopWait()
 }
@@ -51,6 +76,39 @@ func NewServer(protocol string, cb Callback, stop Stopper, tlsConf ...*tls.Confi
 	return nil
 }
 
+// listen opens a TCP listener on host:port, or a unix socket listener when
+// the endpoint is a unix:///path URL.
+func listen(otlpEndpoint string) (net.Listener, error) {
+	if sockfile, ok := strings.CutPrefix(otlpEndpoint, "unix://"); ok {
+		// clean up a stale socket left behind by a server that didn't exit cleanly
+		if fi, err := os.Stat(sockfile); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(sockfile)
+		}
+		return net.Listen("unix", sockfile)
+	}
+
+	return net.Listen("tcp", otlpEndpoint)
+}
+
+// stopWhenDone stops the server with StopWait once a callback reports it's
+// done via the pipeline, or returns once served is closed.
+func stopWhenDone(s OtlpServer, p *Pipeline, served chan struct{}) {
+	select {
+	case <-p.Done():
+		s.StopWait()
+	case <-served:
+	}
+}
+
+// doRequestCallback calls the request callback, if there is one, with the
+// whole export request.
+func doRequestCallback(ctx context.Context, cb RequestCallback, req proto.Message, headers map[string]string, serverMeta map[string]string) bool {
+	if cb == nil {
+		return false
+	}
+	return cb(ctx, req, headers, serverMeta)
+}
+
 // doCallback unwraps the OTLP service request and calls the callback
 // for each span in the request.
 func doCallback(ctx context.Context, cb Callback, req *colv1.ExportTraceServiceRequest, headers map[string]string, serverMeta map[string]string) bool {
@@ -74,3 +132,45 @@ func doCallback(ctx context.Context, cb Callback, req *colv1.ExportTraceServiceR
 
 	return false
 }
+
+// doLogsCallback unwraps the OTLP logs service request and calls the callback
+// for each log record in the request. Logs are accepted and dropped when no
+// callback is set, so SDKs exporting all signals don't error.
+func doLogsCallback(ctx context.Context, cb LogsCallback, req *collogspb.ExportLogsServiceRequest, headers map[string]string, serverMeta map[string]string) bool {
+	if cb == nil {
+		return false
+	}
+
+	for _, resource := range req.GetResourceLogs() {
+		for _, sl := range resource.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				if cb(ctx, lr, resource, headers, serverMeta) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// doMetricsCallback unwraps the OTLP metrics service request and calls the
+// callback for each metric in the request. Metrics are accepted and dropped
+// when no callback is set.
+func doMetricsCallback(ctx context.Context, cb MetricsCallback, req *colmetricspb.ExportMetricsServiceRequest, headers map[string]string, serverMeta map[string]string) bool {
+	if cb == nil {
+		return false
+	}
+
+	for _, resource := range req.GetResourceMetrics() {
+		for _, sm := range resource.GetScopeMetrics() {
+			for _, metric := range sm.GetMetrics() {
+				if cb(ctx, metric, resource, headers, serverMeta) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,194 @@
+package otlpserver
+
+// Zipkin v2 JSON support, so legacy services can be pointed at otel-cli
+// server for local debugging. Zipkin spans are translated to OTLP protobuf
+// and fed through the same Callback as OTLP, so every output mode works
+// without knowing where the spans came from.
+//
+// https://zipkin.io/zipkin-api/#/default/post_spans
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ZipkinSpansPath is the HTTP path Zipkin v2 reporters post spans to.
+const ZipkinSpansPath = "/api/v2/spans"
+
+// ZipkinSpan is a span in Zipkin v2 JSON format.
+type ZipkinSpan struct {
+	TraceId        string             `json:"traceId"`
+	Id             string             `json:"id"`
+	ParentId       string             `json:"parentId,omitempty"`
+	Name           string             `json:"name,omitempty"`
+	Kind           string             `json:"kind,omitempty"`
+	Timestamp      uint64             `json:"timestamp,omitempty"` // microseconds
+	Duration       uint64             `json:"duration,omitempty"`  // microseconds
+	LocalEndpoint  *ZipkinEndpoint    `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *ZipkinEndpoint    `json:"remoteEndpoint,omitempty"`
+	Annotations    []ZipkinAnnotation `json:"annotations,omitempty"`
+	Tags           map[string]string  `json:"tags,omitempty"`
+}
+
+// ZipkinEndpoint is the network context of a Zipkin span.
+type ZipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	Ipv4        string `json:"ipv4,omitempty"`
+	Ipv6        string `json:"ipv6,omitempty"`
+	Port        int64  `json:"port,omitempty"`
+}
+
+// ZipkinAnnotation is a timestamped event on a Zipkin span.
+type ZipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"` // microseconds
+	Value     string `json:"value"`
+}
+
+// ZipkinToResourceSpans translates Zipkin v2 spans to OTLP ResourceSpans,
+// one ResourceSpans per localEndpoint service name, in order of first appearance.
+func ZipkinToResourceSpans(zspans []ZipkinSpan) ([]*tracepb.ResourceSpans, error) {
+	out := []*tracepb.ResourceSpans{}
+	byService := make(map[string]*tracepb.ScopeSpans)
+
+	for _, zs := range zspans {
+		span, err := zipkinToSpan(zs)
+		if err != nil {
+			return nil, err
+		}
+
+		var service string
+		if zs.LocalEndpoint != nil {
+			service = zs.LocalEndpoint.ServiceName
+		}
+
+		ss, ok := byService[service]
+		if !ok {
+			resource := &resourcepb.Resource{Attributes: []*commonpb.KeyValue{}}
+			if service != "" {
+				resource.Attributes = append(resource.Attributes, stringKv("service.name", service))
+			}
+			ss = &tracepb.ScopeSpans{
+				Scope: &commonpb.InstrumentationScope{Name: "zipkin"},
+				Spans: []*tracepb.Span{},
+			}
+			out = append(out, &tracepb.ResourceSpans{
+				Resource:   resource,
+				ScopeSpans: []*tracepb.ScopeSpans{ss},
+			})
+			byService[service] = ss
+		}
+
+		ss.Spans = append(ss.Spans, span)
+	}
+
+	return out, nil
+}
+
+// zipkinToSpan converts a single Zipkin span to an OTLP span.
+func zipkinToSpan(zs ZipkinSpan) (*tracepb.Span, error) {
+	// Zipkin allows 64-bit trace ids, left-pad them out to 128 bits
+	traceId, err := zipkinHexId(zs.TraceId, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zipkin traceId %q: %w", zs.TraceId, err)
+	}
+	spanId, err := zipkinHexId(zs.Id, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zipkin span id %q: %w", zs.Id, err)
+	}
+	parentId := []byte{}
+	if zs.ParentId != "" {
+		parentId, err = zipkinHexId(zs.ParentId, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zipkin parentId %q: %w", zs.ParentId, err)
+		}
+	}
+
+	span := tracepb.Span{
+		TraceId:           traceId,
+		SpanId:            spanId,
+		ParentSpanId:      parentId,
+		Name:              zs.Name,
+		Kind:              zipkinKind(zs.Kind),
+		StartTimeUnixNano: zs.Timestamp * 1000,
+		EndTimeUnixNano:   (zs.Timestamp + zs.Duration) * 1000,
+		Attributes:        []*commonpb.KeyValue{},
+		Events:            []*tracepb.Span_Event{},
+		Links:             []*tracepb.Span_Link{},
+		Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_UNSET},
+	}
+
+	for k, v := range zs.Tags {
+		// Zipkin marks failed spans with an error tag holding the message
+		if k == "error" {
+			span.Status.Code = tracepb.Status_STATUS_CODE_ERROR
+			span.Status.Message = v
+			continue
+		}
+		span.Attributes = append(span.Attributes, stringKv(k, v))
+	}
+
+	if re := zs.RemoteEndpoint; re != nil {
+		if re.ServiceName != "" {
+			span.Attributes = append(span.Attributes, stringKv("peer.service", re.ServiceName))
+		}
+		if re.Ipv4 != "" {
+			span.Attributes = append(span.Attributes, stringKv("net.peer.ip", re.Ipv4))
+		} else if re.Ipv6 != "" {
+			span.Attributes = append(span.Attributes, stringKv("net.peer.ip", re.Ipv6))
+		}
+		if re.Port != 0 {
+			span.Attributes = append(span.Attributes, &commonpb.KeyValue{
+				Key:   "net.peer.port",
+				Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: re.Port}},
+			})
+		}
+	}
+
+	for _, a := range zs.Annotations {
+		span.Events = append(span.Events, &tracepb.Span_Event{
+			TimeUnixNano: a.Timestamp * 1000,
+			Name:         a.Value,
+			Attributes:   []*commonpb.KeyValue{},
+		})
+	}
+
+	return &span, nil
+}
+
+// zipkinHexId decodes a Zipkin hex id, left-padding it with zeroes to size bytes.
+func zipkinHexId(id string, size int) ([]byte, error) {
+	if len(id) == 0 || len(id) > size*2 {
+		return nil, fmt.Errorf("expected 1 to %d hex characters but got %d", size*2, len(id))
+	}
+	return hex.DecodeString(strings.Repeat("0", size*2-len(id)) + id)
+}
+
+// zipkinKind maps Zipkin's span kind strings to OTLP span kinds.
+func zipkinKind(kind string) tracepb.Span_SpanKind {
+	switch strings.ToUpper(kind) {
+	case "CLIENT":
+		return tracepb.Span_SPAN_KIND_CLIENT
+	case "SERVER":
+		return tracepb.Span_SPAN_KIND_SERVER
+	case "PRODUCER":
+		return tracepb.Span_SPAN_KIND_PRODUCER
+	case "CONSUMER":
+		return tracepb.Span_SPAN_KIND_CONSUMER
+	default:
+		// Zipkin spans without a kind are local/in-process
+		return tracepb.Span_SPAN_KIND_INTERNAL
+	}
+}
+
+// stringKv is a shorthand for building a string-valued attribute.
+func stringKv(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
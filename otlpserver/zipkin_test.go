@@ -0,0 +1,91 @@
+package otlpserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestZipkinToResourceSpans(t *testing.T) {
+	js := `[
+		{
+			"traceId": "5af7183fb1d4cf5f",
+			"id": "352bff9a74ca9ad2",
+			"parentId": "6b221d5bc9e6496c",
+			"name": "get /api",
+			"kind": "SERVER",
+			"timestamp": 1556604172355737,
+			"duration": 1431,
+			"localEndpoint": {"serviceName": "backend", "ipv4": "192.168.99.1", "port": 3306},
+			"remoteEndpoint": {"serviceName": "frontend", "ipv4": "172.19.0.2", "port": 58648},
+			"annotations": [{"timestamp": 1556604172355800, "value": "wr"}],
+			"tags": {"http.method": "GET", "error": "boom"}
+		},
+		{
+			"traceId": "5af7183fb1d4cf5f",
+			"id": "6b221d5bc9e6496c",
+			"name": "get",
+			"kind": "CLIENT",
+			"timestamp": 1556604172355000,
+			"duration": 3000,
+			"localEndpoint": {"serviceName": "frontend"}
+		}
+	]`
+
+	zspans := []ZipkinSpan{}
+	if err := json.Unmarshal([]byte(js), &zspans); err != nil {
+		t.Fatalf("failed to parse test json: %s", err)
+	}
+
+	rss, err := ZipkinToResourceSpans(zspans)
+	if err != nil {
+		t.Fatalf("ZipkinToResourceSpans returned an unexpected error: %s", err)
+	}
+	if len(rss) != 2 {
+		t.Fatalf("expected one ResourceSpans per service (2) but got %d", len(rss))
+	}
+
+	if got := rss[0].Resource.Attributes[0].Value.GetStringValue(); got != "backend" {
+		t.Errorf("expected service.name backend but got %q", got)
+	}
+
+	span := rss[0].ScopeSpans[0].Spans[0]
+	if got := hex.EncodeToString(span.TraceId); got != "00000000000000005af7183fb1d4cf5f" {
+		t.Errorf("64-bit trace id was not padded correctly, got %q", got)
+	}
+	if got := hex.EncodeToString(span.ParentSpanId); got != "6b221d5bc9e6496c" {
+		t.Errorf("wrong parent span id %q", got)
+	}
+	if span.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("expected server span kind but got %s", span.Kind)
+	}
+	if span.StartTimeUnixNano != 1556604172355737000 || span.EndTimeUnixNano != 1556604172357168000 {
+		t.Errorf("timestamps were not converted from microseconds, got %d to %d", span.StartTimeUnixNano, span.EndTimeUnixNano)
+	}
+	if span.Status.Code != tracepb.Status_STATUS_CODE_ERROR || span.Status.Message != "boom" {
+		t.Errorf("error tag did not set span status, got %s", span.Status)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "wr" {
+		t.Errorf("annotations were not converted to events: %v", span.Events)
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	if attrs["http.method"] != "GET" || attrs["peer.service"] != "frontend" {
+		t.Errorf("tags or remote endpoint not mapped to attributes: %v", attrs)
+	}
+	if _, ok := attrs["error"]; ok {
+		t.Error("error tag should become span status, not an attribute")
+	}
+}
+
+func TestZipkinToResourceSpansBadId(t *testing.T) {
+	_, err := ZipkinToResourceSpans([]ZipkinSpan{{TraceId: "not-hex", Id: "352bff9a74ca9ad2"}})
+	if err == nil {
+		t.Error("expected an error for an invalid trace id")
+	}
+}